@@ -0,0 +1,47 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package bsaes
+
+import (
+	"crypto/cipher"
+
+	"git.schwanenlied.me/yawning/bsaes.git/internal/aegis"
+)
+
+// NewAEGIS128L returns an AEGIS-128L cipher.AEAD instance, with the 16 byte
+// key, and the specified tag size in bytes (16 or 32).  Nonces are 16 bytes
+// long.
+//
+// AEGIS needs the raw AES round function, so the state is always kept in the
+// 64 bit bitsliced representation, even when NewCipher would use the
+// runtime's implementation.
+func NewAEGIS128L(key []byte, tagSize int) (cipher.AEAD, error) {
+	return aegis.New128L(key, tagSize)
+}
+
+// NewAEGIS256 returns an AEGIS-256 cipher.AEAD instance, with the 32 byte
+// key, and the specified tag size in bytes (16 or 32).  Nonces are 32 bytes
+// long.  The implementation caveats of NewAEGIS128L also apply.
+func NewAEGIS256(key []byte, tagSize int) (cipher.AEAD, error) {
+	return aegis.New256(key, tagSize)
+}
@@ -0,0 +1,241 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package bsaes
+
+import (
+	"crypto/cipher"
+	"encoding/hex"
+	"testing"
+)
+
+// The test vectors are taken from draft-irtf-cfrg-aegis-aead, Appendix A.
+
+type aegisVector struct {
+	key        string
+	nonce      string
+	ad         string
+	plaintext  string
+	ciphertext string
+	tag128     string
+	tag256     string
+}
+
+var aegis128LVectors = []aegisVector{
+	{
+		"10010000000000000000000000000000",
+		"10000200000000000000000000000000",
+		"",
+		"00000000000000000000000000000000",
+		"c1c0e58bd913006feba00f4b3cc3594e",
+		"abe0ece80c24868a226a35d16bdae37a",
+		"25835bfbb21632176cf03840687cb968cace4617af1bd0f7d064c639a5c79ee4",
+	},
+	{
+		"10010000000000000000000000000000",
+		"10000200000000000000000000000000",
+		"",
+		"",
+		"",
+		"c2b879a67def9d74e6c14f708bbcc9b4",
+		"1360dc9db8ae42455f6e5b6a9d488ea4f2184c4e12120249335c4ee84bafe25d",
+	},
+	{
+		"10010000000000000000000000000000",
+		"10000200000000000000000000000000",
+		"0001020304050607",
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+		"79d94593d8c2119d7e8fd9b8fc77845c5c077a05b2528b6ac54b563aed8efe84",
+		"cc6f3372f6aa1bb82388d695c3962d9a",
+		"022cb796fe7e0ae1197525ff67e309484cfbab6528ddef89f17d74ef8ecd82b3",
+	},
+	{
+		"10010000000000000000000000000000",
+		"10000200000000000000000000000000",
+		"0001020304050607",
+		"000102030405060708090a0b0c0d",
+		"79d94593d8c2119d7e8fd9b8fc77",
+		"5c04b3dba849b2701effbe32c7f0fab7",
+		"86f1b80bfb463aba711d15405d094baf4a55a15dbfec81a76f35ed0b9c8b04ac",
+	},
+	{
+		"10010000000000000000000000000000",
+		"10000200000000000000000000000000",
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f20212223242526272829",
+		"101112131415161718191a1b1c1d1e1f202122232425262728292a2b2c2d2e2f3031323334353637",
+		"b31052ad1cca4e291abcf2df3502e6bdb1bfd6db36798be3607b1f94d34478aa7ede7f7a990fec10",
+		"7542a745733014f9474417b337399507",
+		"b91e2947a33da8bee89b6794e647baf0fc835ff574aca3fc27c33be0db2aff98",
+	},
+}
+
+// The draft's vectors are at most a few blocks long, so these longer inputs
+// cross check the multi-block paths against libsodium 1.0.20, which only
+// supports 256 bit tags.
+var aegis128LLongVectors = []aegisVector{
+	{
+		"10010000000000000000000000000000",
+		"10000200000000000000000000000000",
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f202122232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3f404142434445464748494a4b4c",
+		"",
+		"",
+		"",
+		"6fac55c1a6ef12d3be63e4805fc34c1c26f899fa4cc6c1b41bba4aa0543bb72e",
+	},
+	{
+		"10010000000000000000000000000000",
+		"10000200000000000000000000000000",
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f202122232425262728292a2b2c2d2e2f303132333435363738393a3b3c",
+		"101112131415161718191a1b1c1d1e1f202122232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3f404142434445464748494a4b4c4d4e4f505152535455565758595a5b5c5d5e5f606162636465666768696a6b6c6d6e6f707172737475767778797a7b7c7d7e7f808182838485868788898a8b8c8d8e8f909192939495969798999a9b9c9d9e9fa0a1a2a3a4a5a6a7a8a9aaabacadaeafb0b1b2b3b4b5b6b7b8b9babbbcbdbebfc0c1c2c3c4c5c6c7c8c9cacbcccdcecfd0d1d2d3d4d5d6d7d8d9dadbdcdddedfe0e1e2e3e4e5e6e7e8e9eaebecedeeeff0f1f2f3f4f5f6f7f8f9fafbfcfdfeff000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f202122232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3f404142434445464748494a4b4c4d4e4f505152535455565758595a5b5c5d5e5f606162636465666768696a6b6c6d6e6f707172737475767778797a7b7c7d7e7f808182838485868788898a8b8c8d8e8f909192939495969798999a9b9c9d9e9fa0a1a2a3a4a5a6a7a8a9aaabacadaeafb0b1b2b3b4b5b6b7b8b9babbbcbdbebfc0c1c2c3c4c5c6c7c8c9cacbcccdcecfd0d1d2d3d4d5d6d7d8d9dadbdcdddedfe0e1e2e3e4e5e6e7e8e9eaebecedeeeff0f1f2f3f4f5f6f7f8f9fafbfcfdfeff000102030405060708090a0b0c0d0e0f10",
+		"b31052ad1cca4e291abcf2df3502e6bdb1bfd6db36798be3607b1f94d34478aa70562ea33ffebbb6d36a52a8920b98ae19ff8c1185d262b809b030d28d2e491e9ab3dbaafa06c28d28db1b56072a6c47049ba475842050c029e59a067ed68f6a343cf3a2802edb597979dfef1601589aeeb781ef5d12b835b9a631569bd5636e5fcd50efd9211a326fde8cb8f2235777c2ddb99c2ea9de32506260bea60bf1a4f6f329066beb5eed52e887841aad3a18cf8bbb1e778f9732e8f46f3d70f2d32c9953317fc91aea64919d378cf5540eafa84912f21f769aa22e1e41fa37633cc27e22d866a2908bc56bee60145613ea6fc3e3b9e469e9f60ed0f797bd0a11994bd74fd4f002a57b017719a8623dec28c8bce329b9ceaa3c13b738652cd4f72825f831bbea1e934b53ca7bb421f9a8d9b015c9a29ba4eff654855cc84a9e349cd3ff1f1eccdb183e359297e1b85d3ae41820868410f98ad34d1410001f0be5016532489482b0d9e31d3090a2f47a6db83076c70dd5e2ee329e4c9d2103ab39b6b80ab8e23bd7ba5a1880e6c09bf9a767966746f3a7f325b58529d7d2ff5d90a7ffb120b807e4369533885377a5ebade69fc4ee134d32b0c6826958dcec83be1310d540f8f4f257a4a99c4351d087a759d8e4d207212bb507c5fe05458f89a2bb512915771dd6817359ed4675a015b068ffabc8cfd59faeb33841670255443d4a2de2",
+		"",
+		"09f53e7c9cbb68bb1544c052ad49630b1d4364646d6c209d4dc4e4c95a4e8f50",
+	},
+}
+
+var aegis256Vectors = []aegisVector{
+	{
+		"1001000000000000000000000000000000000000000000000000000000000000",
+		"1000020000000000000000000000000000000000000000000000000000000000",
+		"",
+		"00000000000000000000000000000000",
+		"754fc3d8c973246dcc6d741412a4b236",
+		"3fe91994768b332ed7f570a19ec5896e",
+		"1181a1d18091082bf0266f66297d167d2e68b845f61a3b0527d31fc7b7b89f13",
+	},
+	{
+		"1001000000000000000000000000000000000000000000000000000000000000",
+		"1000020000000000000000000000000000000000000000000000000000000000",
+		"",
+		"",
+		"",
+		"e3def978a0f054afd1e761d7553afba3",
+		"6a348c930adbd654896e1666aad67de989ea75ebaa2b82fb588977b1ffec864a",
+	},
+	{
+		"1001000000000000000000000000000000000000000000000000000000000000",
+		"1000020000000000000000000000000000000000000000000000000000000000",
+		"0001020304050607",
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+		"f373079ed84b2709faee373584585d60accd191db310ef5d8b11833df9dec711",
+		"8d86f91ee606e9ff26a01b64ccbdd91d",
+		"b7d28d0c3c0ebd409fd22b44160503073a547412da0854bfb9723020dab8da1a",
+	},
+	{
+		"1001000000000000000000000000000000000000000000000000000000000000",
+		"1000020000000000000000000000000000000000000000000000000000000000",
+		"0001020304050607",
+		"000102030405060708090a0b0c0d",
+		"f373079ed84b2709faee37358458",
+		"c60b9c2d33ceb058f96e6dd03c215652",
+		"8c1cc703c81281bee3f6d9966e14948b4a175b2efbdc31e61a98b4465235c2d9",
+	},
+	{
+		"1001000000000000000000000000000000000000000000000000000000000000",
+		"1000020000000000000000000000000000000000000000000000000000000000",
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f20212223242526272829",
+		"101112131415161718191a1b1c1d1e1f202122232425262728292a2b2c2d2e2f3031323334353637",
+		"57754a7d09963e7c787583a2e7b859bb24fa1e04d49fd550b2511a358e3bca252a9b1b8b30cc4a67",
+		"ab8a7d53fd0e98d727accca94925e128",
+		"a3aca270c006094d71c20e6910b5161c0826df233d08919a566ec2c05990f734",
+	},
+}
+
+// See aegis128LLongVectors.
+var aegis256LongVectors = []aegisVector{
+	{
+		"1001000000000000000000000000000000000000000000000000000000000000",
+		"1000020000000000000000000000000000000000000000000000000000000000",
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f202122232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3f404142434445464748494a4b4c",
+		"",
+		"",
+		"",
+		"a8d10377b7b9e2a375dc531566ecca87643cd10db7d4368fb6e6e70c41c2f988",
+	},
+	{
+		"1001000000000000000000000000000000000000000000000000000000000000",
+		"1000020000000000000000000000000000000000000000000000000000000000",
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f202122232425262728292a2b2c2d2e2f303132333435363738393a3b3c",
+		"101112131415161718191a1b1c1d1e1f202122232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3f404142434445464748494a4b4c4d4e4f505152535455565758595a5b5c5d5e5f606162636465666768696a6b6c6d6e6f707172737475767778797a7b7c7d7e7f808182838485868788898a8b8c8d8e8f909192939495969798999a9b9c9d9e9fa0a1a2a3a4a5a6a7a8a9aaabacadaeafb0b1b2b3b4b5b6b7b8b9babbbcbdbebfc0c1c2c3c4c5c6c7c8c9cacbcccdcecfd0d1d2d3d4d5d6d7d8d9dadbdcdddedfe0e1e2e3e4e5e6e7e8e9eaebecedeeeff0f1f2f3f4f5f6f7f8f9fafbfcfdfeff000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f202122232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3f404142434445464748494a4b4c4d4e4f505152535455565758595a5b5c5d5e5f606162636465666768696a6b6c6d6e6f707172737475767778797a7b7c7d7e7f808182838485868788898a8b8c8d8e8f909192939495969798999a9b9c9d9e9fa0a1a2a3a4a5a6a7a8a9aaabacadaeafb0b1b2b3b4b5b6b7b8b9babbbcbdbebfc0c1c2c3c4c5c6c7c8c9cacbcccdcecfd0d1d2d3d4d5d6d7d8d9dadbdcdddedfe0e1e2e3e4e5e6e7e8e9eaebecedeeeff0f1f2f3f4f5f6f7f8f9fafbfcfdfeff000102030405060708090a0b0c0d0e0f10",
+		"c39d0c41623ef2e659f3634ca405f005f3c84c9c680c042cf4a88a1b905d6dc63371c0d50f8612973e0736e7db08d367284641346a16b788f5a7c17e090d7214a13347358eee3e2614bd2b2740a0981ddb16e8220a0e1e4d023ee414d67a242183f1987af03dfb3fb661f558c8501c0722ffed554b38f3b7cfb67c0ebcf36fa478e252c10cc2c57bf7bfd893fcef5400e9e9a248f613f7424521a41dcd71be44618bf2de76befef37652ce30d72061c1212184d1b4d650ecf545082127fcc5d970916172bcd730aa5ad24d09d411a18099d304a7285cc5cc4cfb604aba9b576f7e7b3d8fa7489233bb342c042cbc5d0be3893140eb01912e785d60b1ec0abf82d4914b5ac9a64d33bd545c20d2122c260c65f01f8105e6eea54e274197da08a7b56b31b9b8cc0469d942da8a61614f69b0bfbebf505f3c162b70759ad0287b9f0217a6e613e121524faebd35a99333a68201dc1950fde11719987456a9c8b002380e4c736abf5e8310ac02566feecb6291fbd6db92bbaca2feba5a17aa133c8d41f378d2ea2db0c6e5977b6750ff3279b4bc3a944eb4d33de432171a88a9c9794960b458fb63ec9702b192695450808cb23e8709a586524d01ecb2400890d80e782bc06b1064c98c68924de3a71b77c1b301af09c55a9727b9bc5d982f2b7b06a0f48a2d80c526d492b75ddda637234c3fb31b2bc3d124c63774abb618b903e4f8",
+		"",
+		"ef3f9112253ea6406c990d3edb81c61edb4ff9da691eddd78c1c289839d02cca",
+	},
+}
+
+func TestAEGIS128L(t *testing.T) {
+	doTestAEGIS(t, NewAEGIS128L, append(aegis128LVectors, aegis128LLongVectors...))
+}
+
+func TestAEGIS256(t *testing.T) {
+	doTestAEGIS(t, NewAEGIS256, append(aegis256Vectors, aegis256LongVectors...))
+}
+
+func doTestAEGIS(t *testing.T, ctor func([]byte, int) (cipher.AEAD, error), vectors []aegisVector) {
+	for i, vec := range vectors {
+		key, err := hex.DecodeString(vec.key[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		nonce, err := hex.DecodeString(vec.nonce[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		ad, err := hex.DecodeString(vec.ad[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		pt, err := hex.DecodeString(vec.plaintext[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, tag := range []string{vec.tag128, vec.tag256} {
+			if tag == "" {
+				continue
+			}
+			ct, err := hex.DecodeString(vec.ciphertext + tag)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			c, err := ctor(key, len(tag)/2)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			dst := c.Seal(nil, nonce, pt, ad)
+			assertEqual(t, i, ct, dst)
+
+			dst, err = c.Open(nil, nonce, ct, ad)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertEqual(t, i, pt, dst)
+
+			ct[len(ct)-1] ^= 0x01
+			if _, err = c.Open(nil, nonce, ct, ad); err == nil {
+				t.Fatalf("[%d] Open succeeded with corrupted tag", i)
+			}
+		}
+	}
+}
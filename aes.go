@@ -31,6 +31,7 @@ import (
 
 	"git.schwanenlied.me/yawning/bsaes.git/ct32"
 	"git.schwanenlied.me/yawning/bsaes.git/ct64"
+	"git.schwanenlied.me/yawning/bsaes.git/internal/deoxys"
 )
 
 // BlockSize is the AES block size in bytes.
@@ -39,6 +40,7 @@ const BlockSize = aes.BlockSize
 var (
 	useCryptoAES = false
 	ctor         = ct64.NewCipher
+	deoxysCtor   = deoxys.NewCt64
 )
 
 type resetAble interface {
@@ -65,6 +67,16 @@ func NewCipher(key []byte) (cipher.Block, error) {
 	return blk, nil
 }
 
+// mustNewCipher is NewCipher for callers that have already validated the key
+// size, and need a constructor to pass to the modes.
+func mustNewCipher(key []byte) cipher.Block {
+	blk, err := NewCipher(key)
+	if err != nil {
+		panic("bsaes: NewCipher failed: " + err.Error())
+	}
+	return blk
+}
+
 // UsingRuntime returns true iff this package is falling through to the
 // runtime's implementation due to hardware support for constant time
 // operation on the current system.
@@ -79,8 +91,10 @@ func init() {
 	switch maxUintptr {
 	case math.MaxUint32:
 		ctor = ct32.NewCipher
+		deoxysCtor = deoxys.NewCt32
 	case math.MaxUint64:
 		ctor = ct64.NewCipher
+		deoxysCtor = deoxys.NewCt64
 	default:
 		panic("bsaes: unsupported architecture")
 	}
@@ -648,6 +648,34 @@ func doBenchGCM(b *testing.B, impl *Impl, ksz, n int) {
 	benchOutput = dst
 }
 
+func doBenchPMAC(b *testing.B, impl *Impl, ksz, n int) {
+	key := make([]byte, ksz)
+
+	if _, err := rand.Read(key[:]); err != nil {
+		b.Error(err)
+		b.Fail()
+	}
+
+	blk := impl.ctor(key[:])
+	pmac, err := NewPMAC(blk)
+	if err != nil {
+		b.Error(err)
+		b.Fail()
+	}
+
+	src := make([]byte, n)
+	var dst []byte
+
+	b.SetBytes(int64(n))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pmac.Reset()
+		pmac.Write(src)
+		dst = pmac.Sum(dst[:0])
+	}
+	benchOutput = dst
+}
+
 func implIsNative(impl *Impl) bool {
 	return impl == nativeImpl || impl == implRuntime
 }
@@ -677,6 +705,10 @@ func doBench(b *testing.B, impl *Impl) {
 		n := fmt.Sprintf("GCM-AES128_%d", sz)
 		b.Run(n, func(b *testing.B) { doBenchGCM(b, impl, 16, sz) })
 	}
+	for _, sz := range []int{16, 64, 256, 1024, 8192, 16384} {
+		n := fmt.Sprintf("PMAC-AES128_%d", sz)
+		b.Run(n, func(b *testing.B) { doBenchPMAC(b, impl, 16, sz) })
+	}
 }
 
 func Benchmark_ct32(b *testing.B) {
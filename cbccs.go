@@ -0,0 +1,73 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package bsaes
+
+import (
+	"crypto/cipher"
+
+	"git.schwanenlied.me/yawning/bsaes.git/internal/modes"
+)
+
+// NewCBCCS1Encrypter returns a cipher.BlockMode which encrypts in CBC mode
+// with ciphertext stealing (CBC-CS1, NIST SP 800-38A Addendum), using the
+// given cipher.Block.  The length of iv must be the same as the Block's
+// block size.
+//
+// Unlike the standard CBC BlockModes, each call to CryptBlocks processes a
+// complete message, which may be any length that is at least one block.
+func NewCBCCS1Encrypter(b cipher.Block, iv []byte) cipher.BlockMode {
+	return modes.NewCBCCSEncrypter(b, iv, modes.CBCCS1)
+}
+
+// NewCBCCS1Decrypter returns a cipher.BlockMode which decrypts in CBC-CS1
+// mode, using the given cipher.Block.  The length of iv must be the same as
+// the Block's block size, and it must match the iv used to encrypt the data.
+func NewCBCCS1Decrypter(b cipher.Block, iv []byte) cipher.BlockMode {
+	return modes.NewCBCCSDecrypter(b, iv, modes.CBCCS1)
+}
+
+// NewCBCCS2Encrypter returns a cipher.BlockMode which encrypts in CBC-CS2
+// mode, which is identical to CBC-CS1, except that the final two blocks are
+// swapped iff the final plaintext block is partial.  See NewCBCCS1Encrypter.
+func NewCBCCS2Encrypter(b cipher.Block, iv []byte) cipher.BlockMode {
+	return modes.NewCBCCSEncrypter(b, iv, modes.CBCCS2)
+}
+
+// NewCBCCS2Decrypter returns a cipher.BlockMode which decrypts in CBC-CS2
+// mode.  See NewCBCCS1Decrypter.
+func NewCBCCS2Decrypter(b cipher.Block, iv []byte) cipher.BlockMode {
+	return modes.NewCBCCSDecrypter(b, iv, modes.CBCCS2)
+}
+
+// NewCBCCS3Encrypter returns a cipher.BlockMode which encrypts in CBC-CS3
+// mode (as used by Kerberos), which is identical to CBC-CS1, except that the
+// final two blocks are always swapped.  See NewCBCCS1Encrypter.
+func NewCBCCS3Encrypter(b cipher.Block, iv []byte) cipher.BlockMode {
+	return modes.NewCBCCSEncrypter(b, iv, modes.CBCCS3)
+}
+
+// NewCBCCS3Decrypter returns a cipher.BlockMode which decrypts in CBC-CS3
+// mode.  See NewCBCCS1Decrypter.
+func NewCBCCS3Decrypter(b cipher.Block, iv []byte) cipher.BlockMode {
+	return modes.NewCBCCSDecrypter(b, iv, modes.CBCCS3)
+}
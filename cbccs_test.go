@@ -0,0 +1,187 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package bsaes
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"testing"
+)
+
+// The test vectors are taken from RFC 3962, Appendix B, which uses CBC-CS3.
+// The CBC-CS1 and CBC-CS2 outputs only differ in the order of the final two
+// blocks, so they are derived from the CBC-CS3 ones.
+
+var cbcCSVectors = []struct {
+	plaintext  string
+	ciphertext string
+}{
+	{
+		"4920776f756c64206c696b652074686520",
+		"c6353568f2bf8cb4d8a580362da7ff7f97",
+	},
+	{
+		"4920776f756c64206c696b65207468652047656e6572616c20476175277320",
+		"fc00783e0efdb2c1d445d4c8eff7ed2297687268d6ecccc0c07b25e25ecfe5",
+	},
+	{
+		"4920776f756c64206c696b65207468652047656e6572616c2047617527732043",
+		"39312523a78662d5be7fcbcc98ebf5a897687268d6ecccc0c07b25e25ecfe584",
+	},
+	{
+		"4920776f756c64206c696b65207468652047656e6572616c20476175277320436869636b656e2c20706c656173652c",
+		"97687268d6ecccc0c07b25e25ecfe584b3fffd940c16a18c1b5549d2f838029e39312523a78662d5be7fcbcc98ebf5",
+	},
+	{
+		"4920776f756c64206c696b65207468652047656e6572616c20476175277320436869636b656e2c20706c656173652c20",
+		"97687268d6ecccc0c07b25e25ecfe5849dad8bbb96c4cdc03bc103e1a194bbd839312523a78662d5be7fcbcc98ebf5a8",
+	},
+	{
+		"4920776f756c64206c696b65207468652047656e6572616c20476175277320436869636b656e2c20706c656173652c20616e6420776f6e746f6e20736f75702e",
+		"97687268d6ecccc0c07b25e25ecfe58439312523a78662d5be7fcbcc98ebf5a84807efe836ee89a526730dbc2f7bc8409dad8bbb96c4cdc03bc103e1a194bbd8",
+	},
+}
+
+// cbcCSReorder converts between the CBC-CS1 and CBC-CS3 orderings of the
+// final two blocks.
+func cbcCSReorder(ct []byte, toCS3 bool) []byte {
+	nFull := (len(ct) - 1) / 16
+	d := len(ct) - nFull*16
+	if nFull == 0 {
+		return ct
+	}
+
+	// CS1: C_1 ... C_{n-2} || C_{n-1}* || C_n
+	// CS3: C_1 ... C_{n-2} || C_n || C_{n-1}*
+	split := 16
+	if toCS3 {
+		split = d
+	}
+	tail := ct[(nFull-1)*16:]
+	out := append([]byte{}, ct[:(nFull-1)*16]...)
+	out = append(out, tail[split:]...)
+	out = append(out, tail[:split]...)
+	return out
+}
+
+func TestCBCCS_RFC3962(t *testing.T) {
+	key, _ := hex.DecodeString("636869636b656e207465726979616b69") // "chicken teriyaki"
+	var iv [16]byte
+
+	variants := []struct {
+		name string
+		enc  func(cipher.Block, []byte) cipher.BlockMode
+		dec  func(cipher.Block, []byte) cipher.BlockMode
+	}{
+		{"CS1", NewCBCCS1Encrypter, NewCBCCS1Decrypter},
+		{"CS2", NewCBCCS2Encrypter, NewCBCCS2Decrypter},
+		{"CS3", NewCBCCS3Encrypter, NewCBCCS3Decrypter},
+	}
+
+	for _, impl := range impls {
+		t.Logf("Testing implementation: %v\n", impl.name)
+		b := impl.ctor(key)
+		for i, vec := range cbcCSVectors {
+			pt, err := hex.DecodeString(vec.plaintext[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			cs3, err := hex.DecodeString(vec.ciphertext[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, v := range variants {
+				ct := cs3
+				switch v.name {
+				case "CS1":
+					ct = cbcCSReorder(cs3, false)
+				case "CS2":
+					if len(pt)%16 == 0 {
+						ct = cbcCSReorder(cs3, false)
+					}
+				}
+
+				dst := make([]byte, len(pt))
+				v.enc(b, iv[:]).CryptBlocks(dst, pt)
+				assertEqual(t, i, ct, dst)
+
+				v.dec(b, iv[:]).CryptBlocks(dst, dst)
+				assertEqual(t, i, pt, dst)
+			}
+		}
+	}
+}
+
+func TestCBCCS_Lengths(t *testing.T) {
+	var key, iv [16]byte
+	for i := range key {
+		key[i] = byte(i)
+		iv[i] = byte(i + 16)
+	}
+	ref, _ := aes.NewCipher(key[:])
+
+	msg := make([]byte, 16*17)
+	for i := range msg {
+		msg[i] = byte(i)
+	}
+
+	for _, impl := range impls {
+		t.Logf("Testing implementation: %v\n", impl.name)
+		b := impl.ctor(key[:])
+		for sz := 16; sz <= len(msg); sz++ {
+			pt := msg[:sz]
+
+			// CBC-CS1 on whole blocks is just CBC.
+			cs1 := make([]byte, sz)
+			NewCBCCS1Encrypter(b, iv[:]).CryptBlocks(cs1, pt)
+			if sz%16 == 0 {
+				expected := make([]byte, sz)
+				cipher.NewCBCEncrypter(ref, iv[:]).CryptBlocks(expected, pt)
+				assertEqual(t, sz, expected, cs1)
+			}
+
+			cs3 := make([]byte, sz)
+			NewCBCCS3Encrypter(b, iv[:]).CryptBlocks(cs3, pt)
+			assertEqual(t, sz, cbcCSReorder(cs1, true), cs3)
+
+			cs2 := make([]byte, sz)
+			NewCBCCS2Encrypter(b, iv[:]).CryptBlocks(cs2, pt)
+			if sz%16 == 0 {
+				assertEqual(t, sz, cs1, cs2)
+			} else {
+				assertEqual(t, sz, cs3, cs2)
+			}
+
+			dst := make([]byte, sz)
+			copy(dst, cs1)
+			NewCBCCS1Decrypter(b, iv[:]).CryptBlocks(dst, dst)
+			assertEqual(t, sz, pt, dst)
+			NewCBCCS2Decrypter(b, iv[:]).CryptBlocks(dst, cs2)
+			assertEqual(t, sz, pt, dst)
+			NewCBCCS3Decrypter(b, iv[:]).CryptBlocks(dst, cs3)
+			assertEqual(t, sz, pt, dst)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package bsaes
+
+import (
+	"crypto/cipher"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+
+	"git.schwanenlied.me/yawning/bsaes.git/internal/modes"
+)
+
+var errCBCHMACKeySize = errors.New("bsaes: invalid AES-CBC-HMAC-SHA2 key size")
+
+// NewCBCHMACSHA256 returns an AES_128_CBC_HMAC_SHA_256 (RFC 7518, Section
+// 5.2.3) cipher.AEAD instance, with the 32 byte key.  The nonce is the 16
+// byte CBC IV, and must be unpredictable.
+func NewCBCHMACSHA256(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, errCBCHMACKeySize
+	}
+	return modes.NewCBCHMAC(mustNewCipher, key, sha256.New, 16)
+}
+
+// NewCBCHMACSHA384 returns an AES_192_CBC_HMAC_SHA_384 (RFC 7518, Section
+// 5.2.4) cipher.AEAD instance, with the 48 byte key.  The nonce is as in
+// NewCBCHMACSHA256.
+func NewCBCHMACSHA384(key []byte) (cipher.AEAD, error) {
+	if len(key) != 48 {
+		return nil, errCBCHMACKeySize
+	}
+	return modes.NewCBCHMAC(mustNewCipher, key, sha512.New384, 24)
+}
+
+// NewCBCHMACSHA512 returns an AES_256_CBC_HMAC_SHA_512 (RFC 7518, Section
+// 5.2.5) cipher.AEAD instance, with the 64 byte key.  The nonce is as in
+// NewCBCHMACSHA256.
+func NewCBCHMACSHA512(key []byte) (cipher.AEAD, error) {
+	if len(key) != 64 {
+		return nil, errCBCHMACKeySize
+	}
+	return modes.NewCBCHMAC(mustNewCipher, key, sha512.New, 32)
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package bsaes
+
+import (
+	"crypto/cipher"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"hash"
+	"testing"
+
+	"git.schwanenlied.me/yawning/bsaes.git/internal/modes"
+)
+
+// The test vectors are taken from RFC 7518, Appendix B.
+
+var cbcHMACVectors = []struct {
+	h          func() hash.Hash
+	tagSize    int
+	key        string
+	nonce      string
+	aad        string
+	plaintext  string
+	ciphertext string
+}{
+	// B.1 AES_128_CBC_HMAC_SHA_256
+	{
+		sha256.New,
+		16,
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+		"1af38c2dc2b96ffdd86694092341bc04",
+		"546865207365636f6e64207072696e6369706c65206f662041756775737465204b6572636b686f666673",
+		"41206369706865722073797374656d206d757374206e6f7420626520726571756972656420746f206265207365637265742c20616e64206974206d7573742062652061626c6520746f2066616c6c20696e746f207468652068616e6473206f662074686520656e656d7920776974686f757420696e636f6e76656e69656e6365",
+		"c80edfa32ddf39d5ef00c0b468834279a2e46a1b8049f792f76bfe54b903a9c9a94ac9b47ad2655c5f10f9aef71427e2fc6f9b3f399a221489f16362c703233609d45ac69864e3321cf82935ac4096c86e133314c54019e8ca7980dfa4b9cf1b384c486f3a54c51078158ee5d79de59fbd34d848b3d69550a67646344427ade54b8851ffb598f7f80074b9473c82e2db652c3fa36b0a7c5b3219fab3a30bc1c4",
+	},
+	// B.2 AES_192_CBC_HMAC_SHA_384
+	{
+		sha512.New384,
+		24,
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f202122232425262728292a2b2c2d2e2f",
+		"1af38c2dc2b96ffdd86694092341bc04",
+		"546865207365636f6e64207072696e6369706c65206f662041756775737465204b6572636b686f666673",
+		"41206369706865722073797374656d206d757374206e6f7420626520726571756972656420746f206265207365637265742c20616e64206974206d7573742062652061626c6520746f2066616c6c20696e746f207468652068616e6473206f662074686520656e656d7920776974686f757420696e636f6e76656e69656e6365",
+		"ea65da6b59e61edb419be62d19712ae5d303eeb50052d0dfd6697f77224c8edb000d279bdc14c1072654bd30944230c657bed4ca0c9f4a8466f22b226d1746214bf8cfc2400add9f5126e479663fc90b3bed787a2f0ffcbf3904be2a641d5c2105bfe591bae23b1d7449e532eef60a9ac8bb6c6b01d35d49787bcd57ef484927f280adc91ac0c4e79c7b11efc60054e38490ac0e58949bfe51875d733f93ac2075168039ccc733d7",
+	},
+	// B.3 AES_256_CBC_HMAC_SHA_512
+	{
+		sha512.New,
+		32,
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f202122232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3f",
+		"1af38c2dc2b96ffdd86694092341bc04",
+		"546865207365636f6e64207072696e6369706c65206f662041756775737465204b6572636b686f666673",
+		"41206369706865722073797374656d206d757374206e6f7420626520726571756972656420746f206265207365637265742c20616e64206974206d7573742062652061626c6520746f2066616c6c20696e746f207468652068616e6473206f662074686520656e656d7920776974686f757420696e636f6e76656e69656e6365",
+		"4affaaadb78c31c5da4b1b590d10ffbd3dd8d5d302423526912da037ecbcc7bd822c301dd67c373bccb584ad3e9279c2e6d12a1374b77f077553df829410446b36ebd97066296ae6427ea75c2e0846a11a09ccf5370dc80bfecbad28c73f09b3a3b75e662a2594410ae496b2e2e6609e31e6e02cc837f053d21f37ff4f51950bbe2638d09dd7a4930930806d0703b1f64dd3b4c088a7f45c216839645b2012bf2e6269a8c56a816dbc1b267761955bc5",
+	},
+}
+
+func TestCBCHMAC_RFC7518(t *testing.T) {
+	for _, impl := range impls {
+		t.Logf("Testing implementation: %v\n", impl.name)
+		for i, vec := range cbcHMACVectors {
+			key, err := hex.DecodeString(vec.key[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			nonce, err := hex.DecodeString(vec.nonce[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			aad, err := hex.DecodeString(vec.aad[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			pt, err := hex.DecodeString(vec.plaintext[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			ct, err := hex.DecodeString(vec.ciphertext[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			c, err := modes.NewCBCHMAC(impl.ctor, key, vec.h, vec.tagSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			dst := c.Seal(nil, nonce, pt, aad)
+			assertEqual(t, i, ct, dst)
+
+			dst, err = c.Open(nil, nonce, ct, aad)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertEqual(t, i, pt, dst)
+
+			ct[0] ^= 0x01
+			if _, err = c.Open(nil, nonce, ct, aad); err == nil {
+				t.Fatalf("[%d] Open succeeded with corrupted ciphertext", i)
+			}
+		}
+	}
+}
+
+func TestCBCHMAC_Lengths(t *testing.T) {
+	ctors := []func([]byte) (cipher.AEAD, error){
+		NewCBCHMACSHA256,
+		NewCBCHMACSHA384,
+		NewCBCHMACSHA512,
+	}
+
+	msg := make([]byte, 100)
+	for i := range msg {
+		msg[i] = byte(i)
+	}
+	nonce := make([]byte, 16)
+
+	for i, ctor := range ctors {
+		key := make([]byte, 32+i*16)
+		c, err := ctor(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = ctor(key[1:]); err == nil {
+			t.Fatalf("[%d] constructor accepted an invalid key size", i)
+		}
+
+		for sz := 0; sz <= len(msg); sz++ {
+			ct := c.Seal(nil, nonce, msg[:sz], nil)
+			if len(ct) != (sz/16+1)*16+c.Overhead()-16 {
+				t.Fatalf("[%d] unexpected ciphertext length: %d", sz, len(ct))
+			}
+			pt, err := c.Open(nil, nonce, ct, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertEqual(t, sz, msg[:sz], pt)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package bsaes
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// The test vectors are taken from NIST Special Pub. 800-38C, Appendix C, and
+// RFC 3610, Section 8.
+//
+// http://nvlpubs.nist.gov/nistpubs/Legacy/SP/nistspecialpublication800-38c.pdf
+
+var ccmVectors = []struct {
+	key        string
+	nonce      string
+	a          string
+	plaintext  string
+	ciphertext string
+	tagSize    int
+}{
+	// SP 800-38C Example 1
+	{
+		"404142434445464748494a4b4c4d4e4f",
+		"10111213141516",
+		"0001020304050607",
+		"20212223",
+		"7162015b4dac255d",
+		4,
+	},
+	// SP 800-38C Example 2
+	{
+		"404142434445464748494a4b4c4d4e4f",
+		"1011121314151617",
+		"000102030405060708090a0b0c0d0e0f",
+		"202122232425262728292a2b2c2d2e2f",
+		"d2a1f0e051ea5f62081a7792073d593d1fc64fbfaccd",
+		6,
+	},
+	// SP 800-38C Example 3
+	{
+		"404142434445464748494a4b4c4d4e4f",
+		"101112131415161718191a1b",
+		"000102030405060708090a0b0c0d0e0f10111213",
+		"202122232425262728292a2b2c2d2e2f3031323334353637",
+		"e3b201a9f5b71a7a9b1ceaeccd97e70b6176aad9a4428aa5484392fbc1b09951",
+		8,
+	},
+	// RFC 3610 Packet Vector #1
+	{
+		"c0c1c2c3c4c5c6c7c8c9cacbcccdcecf",
+		"00000003020100a0a1a2a3a4a5",
+		"0001020304050607",
+		"08090a0b0c0d0e0f101112131415161718191a1b1c1d1e",
+		"588c979a61c663d2f066d0c2c0f989806d5f6b61dac38417e8d12cfdf926e0",
+		8,
+	},
+	// RFC 3610 Packet Vector #2
+	{
+		"c0c1c2c3c4c5c6c7c8c9cacbcccdcecf",
+		"00000004030201a0a1a2a3a4a5",
+		"0001020304050607",
+		"08090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+		"72c91a36e135f8cf291ca894085c87e3cc15c439c9e43a3ba091d56e10400916",
+		8,
+	},
+	// RFC 3610 Packet Vector #3
+	{
+		"c0c1c2c3c4c5c6c7c8c9cacbcccdcecf",
+		"00000005040302a0a1a2a3a4a5",
+		"0001020304050607",
+		"08090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f20",
+		"51b1e5f44a197d1da46b0f8e2d282ae871e838bb64da8596574adaa76fbd9fb0c5",
+		8,
+	},
+	// RFC 3610 Packet Vector #4
+	{
+		"c0c1c2c3c4c5c6c7c8c9cacbcccdcecf",
+		"00000006050403a0a1a2a3a4a5",
+		"000102030405060708090a0b",
+		"0c0d0e0f101112131415161718191a1b1c1d1e",
+		"a28c6865939a9a79faaa5c4c2a9d4a91cdac8c96c861b9c9e61ef1",
+		8,
+	},
+	// RFC 3610 Packet Vector #7
+	{
+		"c0c1c2c3c4c5c6c7c8c9cacbcccdcecf",
+		"00000009080706a0a1a2a3a4a5",
+		"0001020304050607",
+		"08090a0b0c0d0e0f101112131415161718191a1b1c1d1e",
+		"0135d1b2c95f41d5d1d4fec185d166b8094e999dfed96c048c56602c97acbb7490",
+		10,
+	},
+	// RFC 3610 Packet Vector #12
+	{
+		"c0c1c2c3c4c5c6c7c8c9cacbcccdcecf",
+		"0000000e0d0c0ba0a1a2a3a4a5",
+		"000102030405060708090a0b",
+		"0c0d0e0f101112131415161718191a1b1c1d1e1f20",
+		"c0ffa0d6f05bdb67f24d43a4338d2aa4bed7b20e43cd1aa31662e7ad65d6db",
+		10,
+	},
+	// RFC 3610 Packet Vector #13
+	{
+		"d7828d13b2b0bdc325a76236df93cc6b",
+		"00412b4ea9cdbe3c9696766cfa",
+		"0be1a88bace018b1",
+		"08e8cf97d820ea258460e96ad9cf5289054d895ceac47c",
+		"4cb97f86a2a4689a877947ab8091ef5386a6ffbdd080f8e78cf7cb0cddd7b3",
+		8,
+	},
+	// RFC 3610 Packet Vector #14
+	{
+		"d7828d13b2b0bdc325a76236df93cc6b",
+		"0033568ef7b2633c9696766cfa",
+		"63018f76dc8a1bcb",
+		"9020ea6f91bdd85afa0039ba4baff9bfb79c7028949cd0ec",
+		"4ccb1e7ca981befaa0726c55d378061298c85c92814abc33c52ee81d7d77c08a",
+		8,
+	},
+	// RFC 3610 Packet Vector #16
+	{
+		"d7828d13b2b0bdc325a76236df93cc6b",
+		"00f8b678094e3b3c9696766cfa",
+		"77b60f011c03e1525899bcae",
+		"e88b6a46c78d63e52eb8c546efb5de6f75e9cc0d",
+		"5545ff1a085ee2efbf52b2e04bee1e2336c73e3f762c0c7744fe7e3c",
+		8,
+	},
+}
+
+func TestCCM(t *testing.T) {
+	for _, impl := range impls {
+		t.Logf("Testing implementation: %v\n", impl.name)
+		for i, vec := range ccmVectors {
+			key, err := hex.DecodeString(vec.key[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			nonce, err := hex.DecodeString(vec.nonce[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			a, err := hex.DecodeString(vec.a[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			pt, err := hex.DecodeString(vec.plaintext[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			ct, err := hex.DecodeString(vec.ciphertext[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			c, err := NewCCM(impl.ctor(key), len(nonce), vec.tagSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			dst := c.Seal(nil, nonce, pt, a)
+			assertEqual(t, i, ct, dst)
+
+			dst, err = c.Open(nil, nonce, ct, a)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertEqual(t, i, pt, dst)
+
+			ct[len(ct)-1] ^= 0x01
+			if _, err = c.Open(nil, nonce, ct, a); err == nil {
+				t.Fatalf("[%d] Open succeeded with corrupted tag", i)
+			}
+		}
+	}
+}
+
+func TestCCM_SP800_38C_Example4(t *testing.T) {
+	key, _ := hex.DecodeString("404142434445464748494a4b4c4d4e4f")
+	nonce, _ := hex.DecodeString("101112131415161718191a1b1c")
+	pt, _ := hex.DecodeString("202122232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3f")
+	ct, _ := hex.DecodeString("69915dad1e84c6376a68c2967e4dab615ae0fd1faec44cc484828529463ccf72b4ac6bec93e8598e7f0dadbcea5b")
+
+	// The associated data is 524288 bits long, and is formed by repeating
+	// the sequence 0x00 ... 0xff 256 times.
+	a := make([]byte, 65536)
+	for i := range a {
+		a[i] = byte(i)
+	}
+
+	for _, impl := range impls {
+		t.Logf("Testing implementation: %v\n", impl.name)
+
+		c, err := NewCCM(impl.ctor(key), len(nonce), 14)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		dst := c.Seal(nil, nonce, pt, a)
+		assertEqual(t, 0, ct, dst)
+
+		dst, err = c.Open(nil, nonce, ct, a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertEqual(t, 0, pt, dst)
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package bsaes
+
+import (
+	"crypto/cipher"
+
+	"git.schwanenlied.me/yawning/bsaes.git/internal/modes"
+)
+
+// NewCFBEncrypter returns a cipher.Stream which encrypts with CFB-128 mode,
+// using the given cipher.Block.  The iv must be the same length as the
+// Block's block size.
+func NewCFBEncrypter(b cipher.Block, iv []byte) cipher.Stream {
+	return modes.NewCFB(b, iv, false)
+}
+
+// NewCFBDecrypter returns a cipher.Stream which decrypts with CFB-128 mode,
+// using the given cipher.Block.  The iv must be the same length as the
+// Block's block size.  Unlike the `crypto/cipher` implementation, multiple
+// blocks are decrypted in parallel.
+func NewCFBDecrypter(b cipher.Block, iv []byte) cipher.Stream {
+	return modes.NewCFB(b, iv, true)
+}
+
+// NewCFB8Encrypter returns a cipher.Stream which encrypts with CFB-8 mode,
+// using the given cipher.Block.  The iv must be the same length as the
+// Block's block size.
+func NewCFB8Encrypter(b cipher.Block, iv []byte) cipher.Stream {
+	return modes.NewCFB8(b, iv, false)
+}
+
+// NewCFB8Decrypter returns a cipher.Stream which decrypts with CFB-8 mode,
+// using the given cipher.Block.  The iv must be the same length as the
+// Block's block size.  Multiple bytes are decrypted in parallel.
+func NewCFB8Decrypter(b cipher.Block, iv []byte) cipher.Stream {
+	return modes.NewCFB8(b, iv, true)
+}
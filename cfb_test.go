@@ -0,0 +1,183 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package bsaes
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"testing"
+)
+
+// The test vectors are taken from NIST Special Pub. 800-38A, Appendix F.3.
+
+var cfbVectors = []struct {
+	key        string
+	iv         string
+	plaintext  string
+	ciphertext string
+	cfb8       bool
+}{
+	// F.3.7 CFB8-AES128.Encrypt
+	{
+		"2b7e151628aed2a6abf7158809cf4f3c",
+		"000102030405060708090a0b0c0d0e0f",
+		"6bc1bee22e409f96e93d7e117393172aae2d",
+		"3b79424c9c0dd436bace9e0ed4586a4f32b9",
+		true,
+	},
+	// F.3.9 CFB8-AES192.Encrypt
+	{
+		"8e73b0f7da0e6452c810f32b809079e562f8ead2522c6b7b",
+		"000102030405060708090a0b0c0d0e0f",
+		"6bc1bee22e409f96e93d7e117393172aae2d",
+		"cda2521ef0a905ca44cd057cbf0d47a0678a",
+		true,
+	},
+	// F.3.11 CFB8-AES256.Encrypt
+	{
+		"603deb1015ca71be2b73aef0857d77811f352c073b6108d72d9810a30914dff4",
+		"000102030405060708090a0b0c0d0e0f",
+		"6bc1bee22e409f96e93d7e117393172aae2d",
+		"dc1f1a8520a64db55fcc8ac554844e889700",
+		true,
+	},
+	// F.3.13 CFB128-AES128.Encrypt
+	{
+		"2b7e151628aed2a6abf7158809cf4f3c",
+		"000102030405060708090a0b0c0d0e0f",
+		"6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e5130c81c46a35ce411e5fbc1191a0a52eff69f2445df4f9b17ad2b417be66c3710",
+		"3b3fd92eb72dad20333449f8e83cfb4ac8a64537a0b3a93fcde3cdad9f1ce58b26751f67a3cbb140b1808cf187a4f4dfc04b05357c5d1c0eeac4c66f9ff7f2e6",
+		false,
+	},
+	// F.3.15 CFB128-AES192.Encrypt
+	{
+		"8e73b0f7da0e6452c810f32b809079e562f8ead2522c6b7b",
+		"000102030405060708090a0b0c0d0e0f",
+		"6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e5130c81c46a35ce411e5fbc1191a0a52eff69f2445df4f9b17ad2b417be66c3710",
+		"cdc80d6fddf18cab34c25909c99a417467ce7f7f81173621961a2b70171d3d7a2e1e8a1dd59b88b1c8e60fed1efac4c9c05f9f9ca9834fa042ae8fba584b09ff",
+		false,
+	},
+	// F.3.17 CFB128-AES256.Encrypt
+	{
+		"603deb1015ca71be2b73aef0857d77811f352c073b6108d72d9810a30914dff4",
+		"000102030405060708090a0b0c0d0e0f",
+		"6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e5130c81c46a35ce411e5fbc1191a0a52eff69f2445df4f9b17ad2b417be66c3710",
+		"dc7e84bfda79164b7ecd8486985d386039ffed143b28b1c832113c6331e5407bdf10132415e54b92a13ed0a8267ae2f975a385741ab9cef82031623d55b1e471",
+		false,
+	},
+}
+
+func TestCFB_SP800_38A(t *testing.T) {
+	for _, impl := range impls {
+		t.Logf("Testing implementation: %v\n", impl.name)
+		for i, vec := range cfbVectors {
+			key, err := hex.DecodeString(vec.key[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			iv, err := hex.DecodeString(vec.iv[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			pt, err := hex.DecodeString(vec.plaintext[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			ct, err := hex.DecodeString(vec.ciphertext[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			newEnc, newDec := NewCFBEncrypter, NewCFBDecrypter
+			if vec.cfb8 {
+				newEnc, newDec = NewCFB8Encrypter, NewCFB8Decrypter
+			}
+
+			b := impl.ctor(key)
+			dst := make([]byte, len(pt))
+			newEnc(b, iv).XORKeyStream(dst, pt)
+			assertEqual(t, i, ct, dst)
+
+			newDec(b, iv).XORKeyStream(dst, dst)
+			assertEqual(t, i, pt, dst)
+		}
+	}
+}
+
+func TestCFB_Chunked(t *testing.T) {
+	var key, iv [16]byte
+	for i := range key {
+		key[i] = byte(i)
+		iv[i] = byte(i + 16)
+	}
+	ref, _ := aes.NewCipher(key[:])
+
+	msg := make([]byte, 1024)
+	for i := range msg {
+		msg[i] = byte(i)
+	}
+	expected := make([]byte, len(msg))
+	cipher.NewCFBEncrypter(ref, iv[:]).XORKeyStream(expected, msg)
+
+	for _, impl := range impls {
+		t.Logf("Testing implementation: %v\n", impl.name)
+		b := impl.ctor(key[:])
+
+		// Splitting the input at unaligned offsets must not matter.
+		for _, chunkSz := range []int{1, 7, 16, 33, 64, 129} {
+			ct := make([]byte, len(msg))
+			enc := NewCFBEncrypter(b, iv[:])
+			for off := 0; off < len(msg); off += chunkSz {
+				end := off + chunkSz
+				if end > len(msg) {
+					end = len(msg)
+				}
+				enc.XORKeyStream(ct[off:end], msg[off:end])
+			}
+			assertEqual(t, chunkSz, expected, ct)
+
+			dec := NewCFBDecrypter(b, iv[:])
+			for off := 0; off < len(ct); off += chunkSz {
+				end := off + chunkSz
+				if end > len(ct) {
+					end = len(ct)
+				}
+				dec.XORKeyStream(ct[off:end], ct[off:end])
+			}
+			assertEqual(t, chunkSz, msg, ct)
+
+			ct8 := make([]byte, len(msg))
+			NewCFB8Encrypter(b, iv[:]).XORKeyStream(ct8, msg)
+			dec8 := NewCFB8Decrypter(b, iv[:])
+			for off := 0; off < len(ct8); off += chunkSz {
+				end := off + chunkSz
+				if end > len(ct8) {
+					end = len(ct8)
+				}
+				dec8.XORKeyStream(ct8[off:end], ct8[off:end])
+			}
+			assertEqual(t, chunkSz, msg, ct8)
+		}
+	}
+}
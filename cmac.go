@@ -0,0 +1,49 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package bsaes
+
+import (
+	"crypto/cipher"
+	"hash"
+
+	"git.schwanenlied.me/yawning/bsaes.git/internal/modes"
+)
+
+// NewCMAC returns a new CMAC (SP 800-38B, also known as OMAC1) hash.Hash,
+// keyed by the cipher.Block b.  With an AES-128 b, this is AES-CMAC as in
+// RFC 4493.
+func NewCMAC(b cipher.Block) (hash.Hash, error) {
+	return modes.NewCMAC(b)
+}
+
+// NewCMACPRF128 returns a new AES-CMAC-PRF-128 (RFC 4615) hash.Hash, with
+// the arbitrary length key.
+func NewCMACPRF128(key []byte) hash.Hash {
+	return modes.NewCMACPRF128(func(k []byte) cipher.Block {
+		blk, err := NewCipher(k)
+		if err != nil {
+			panic("bsaes/NewCMACPRF128: NewCipher failed: " + err.Error())
+		}
+		return blk
+	}, key)
+}
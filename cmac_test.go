@@ -0,0 +1,140 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package bsaes
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"git.schwanenlied.me/yawning/bsaes.git/internal/modes"
+)
+
+// The test vectors are taken from NIST Special Pub. 800-38B, Appendix D
+// (which are also the AES-128 vectors in RFC 4493, Section 4).
+
+const cmacMessage = "6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e5130c81c46a35ce411e5fbc1191a0a52eff69f2445df4f9b17ad2b417be66c3710"
+
+var cmacVectors = []struct {
+	key    string
+	msgLen int
+	tag    string
+}{
+	// D.1 AES-128
+	{"2b7e151628aed2a6abf7158809cf4f3c", 0, "bb1d6929e95937287fa37d129b756746"},
+	{"2b7e151628aed2a6abf7158809cf4f3c", 16, "070a16b46b4d4144f79bdd9dd04a287c"},
+	{"2b7e151628aed2a6abf7158809cf4f3c", 40, "dfa66747de9ae63030ca32611497c827"},
+	{"2b7e151628aed2a6abf7158809cf4f3c", 64, "51f0bebf7e3b9d92fc49741779363cfe"},
+
+	// D.2 AES-192
+	{"8e73b0f7da0e6452c810f32b809079e562f8ead2522c6b7b", 0, "d17ddf46adaacde531cac483de7a9367"},
+	{"8e73b0f7da0e6452c810f32b809079e562f8ead2522c6b7b", 16, "9e99a7bf31e710900662f65e617c5184"},
+	{"8e73b0f7da0e6452c810f32b809079e562f8ead2522c6b7b", 40, "8a1de5be2eb31aad089a82e6ee908b0e"},
+	{"8e73b0f7da0e6452c810f32b809079e562f8ead2522c6b7b", 64, "a1d5df0eed790f794d77589659f39a11"},
+
+	// D.3 AES-256
+	{"603deb1015ca71be2b73aef0857d77811f352c073b6108d72d9810a30914dff4", 0, "028962f61b7bf89efc6b551f4667d983"},
+	{"603deb1015ca71be2b73aef0857d77811f352c073b6108d72d9810a30914dff4", 16, "28a7023f452e8f82bd4bf28d8c37c35c"},
+	{"603deb1015ca71be2b73aef0857d77811f352c073b6108d72d9810a30914dff4", 40, "aaf3d8f1de5640c232f5b169b9c911e6"},
+	{"603deb1015ca71be2b73aef0857d77811f352c073b6108d72d9810a30914dff4", 64, "e1992190549f6ed5696a2c056c315410"},
+}
+
+func TestCMAC(t *testing.T) {
+	msg, err := hex.DecodeString(cmacMessage)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, impl := range impls {
+		t.Logf("Testing implementation: %v\n", impl.name)
+		for i, vec := range cmacVectors {
+			key, err := hex.DecodeString(vec.key[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			tag, err := hex.DecodeString(vec.tag[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			h, err := NewCMAC(impl.ctor(key))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			h.Write(msg[:vec.msgLen])
+			assertEqual(t, i, tag, h.Sum(nil))
+
+			// Sum must not change the underlying state, and incremental
+			// writes must give the same result.
+			h.Reset()
+			for _, v := range msg[:vec.msgLen] {
+				h.Write([]byte{v})
+			}
+			assertEqual(t, i, tag, h.Sum(nil))
+			assertEqual(t, i, tag, h.Sum(nil))
+		}
+	}
+}
+
+// The test vectors are taken from RFC 4615, Section 4.
+
+var cmacPRF128Vectors = []struct {
+	key    string
+	output string
+}{
+	{"000102030405060708090a0b0c0d0e0fedcb", "84a348a4a45d235babfffc0d2b4da09a"},
+	{"000102030405060708090a0b0c0d0e0f", "980ae87b5f4c9c5214f5b6a8455e4c2d"},
+	{"00010203040506070809", "290d9e112edb09ee141fcf64c0b72f3d"},
+}
+
+func TestCMACPRF128_RFC4615(t *testing.T) {
+	msg, err := hex.DecodeString("000102030405060708090a0b0c0d0e0f10111213")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, impl := range impls {
+		t.Logf("Testing implementation: %v\n", impl.name)
+		for i, vec := range cmacPRF128Vectors {
+			key, err := hex.DecodeString(vec.key[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			output, err := hex.DecodeString(vec.output[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			h := modes.NewCMACPRF128(impl.ctor, key)
+			h.Write(msg)
+			assertEqual(t, i, output, h.Sum(nil))
+		}
+	}
+
+	// Exercise the public constructor as well.
+	key, _ := hex.DecodeString(cmacPRF128Vectors[0].key)
+	output, _ := hex.DecodeString(cmacPRF128Vectors[0].output)
+	h := NewCMACPRF128(key)
+	h.Write(msg)
+	assertEqual(t, 0, output, h.Sum(nil))
+}
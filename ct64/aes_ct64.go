@@ -566,7 +566,7 @@ func NewCipher(key []byte) cipher.Block {
 	b.numRounds = Keysched(skey[:], key)
 	SkeyExpand(b.skExp[:], b.numRounds, skey[:])
 
-	b.BlockModesImpl.Init(b)
+	b.BlockModesImpl.Init(b, NewCipher)
 
 	return b
 }
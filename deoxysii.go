@@ -0,0 +1,37 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package bsaes
+
+import "crypto/cipher"
+
+// NewDeoxysII returns a Deoxys-II nonce misuse resistant cipher.AEAD
+// instance.  The key argument should be either 16 or 32 bytes to select
+// Deoxys-II-128-128 (Deoxys-BC-256) or Deoxys-II-256-128 (Deoxys-BC-384).
+// Nonces are 15 bytes long, and tags are 16 bytes long.
+//
+// Deoxys-BC needs the raw AES round function, so the bitsliced
+// implementation is always used, even when NewCipher would use the runtime's
+// implementation.
+func NewDeoxysII(key []byte) (cipher.AEAD, error) {
+	return deoxysCtor(key)
+}
@@ -0,0 +1,177 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package bsaes
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// The test vectors are taken from "The EAX Mode of Operation" by Bellare,
+// Rogaway, and Wagner, Appendix E.
+//
+// https://web.cs.ucdavis.edu/~rogaway/papers/eax.pdf
+
+var eaxVectors = []struct {
+	key        string
+	nonce      string
+	header     string
+	plaintext  string
+	ciphertext string
+}{
+	{
+		"233952dee4d5ed5f9b9c6d6ff80ff478",
+		"62ec67f9c3a4a407fcb2a8c49031a8b3",
+		"6bfb914fd07eae6b",
+		"",
+		"e037830e8389f27b025a2d6527e79d01",
+	},
+	{
+		"91945d3f4dcbee0bf45ef52255f095a4",
+		"becaf043b0a23d843194ba972c66debd",
+		"fa3bfd4806eb53fa",
+		"f7fb",
+		"19dd5c4c9331049d0bdab0277408f67967e5",
+	},
+	{
+		"01f74ad64077f2e704c0f60ada3dd523",
+		"70c3db4f0d26368400a10ed05d2bff5e",
+		"234a3463c1264ac6",
+		"1a47cb4933",
+		"d851d5bae03a59f238a23e39199dc9266626c40f80",
+	},
+	{
+		"d07cf6cbb7f313bdde66b727afd3c5e8",
+		"8408dfff3c1a2b1292dc199e46b7d617",
+		"33cce2eabff5a79d",
+		"481c9e39b1",
+		"632a9d131ad4c168a4225d8e1ff755939974a7bede",
+	},
+	{
+		"35b6d0580005bbc12b0587124557d2c2",
+		"fdb6b06676eedc5c61d74276e1f8e816",
+		"aeb96eaebe2970e9",
+		"40d0c07da5e4",
+		"071dfe16c675cb0677e536f73afe6a14b74ee49844dd",
+	},
+	{
+		"bd8e6e11475e60b268784c38c62feb22",
+		"6eac5c93072d8e8513f750935e46da1b",
+		"d4482d1ca78dce0f",
+		"4de3b35c3fc039245bd1fb7d",
+		"835bb4f15d743e350e728414abb8644fd6ccb86947c5e10590210a4f",
+	},
+	{
+		"7c77d6e813bed5ac98baa417477a2e7d",
+		"1a8c98dcd73d38393b2bf1569deefc19",
+		"65d2017990d62528",
+		"8b0a79306c9ce7ed99dae4f87f8dd61636",
+		"02083e3979da014812f59f11d52630da30137327d10649b0aa6e1c181db617d7f2",
+	},
+	{
+		"5fff20cafab119ca2fc73549e20f5b0d",
+		"dde59b97d722156d4d9aff2bc7559826",
+		"54b9f04e6a09189a",
+		"1bda122bce8a8dbaf1877d962b8592dd2d56",
+		"2ec47b2c4954a489afc7ba4897edcdae8cc33b60450599bd02c96382902aef7f832a",
+	},
+	{
+		"a4a4782bcffd3ec5e7ef6d8c34a56123",
+		"b781fcf2f75fa5a8de97a9ca48e522ec",
+		"899a175897561d7e",
+		"6cf36720872b8513f6eab1a8a44438d5ef11",
+		"0de18fd0fdd91e7af19f1d8ee8733938b1e8e7f6d2231618102fdb7fe55ff1991700",
+	},
+	{
+		"8395fcf1e95bebd697bd010bc766aac3",
+		"22e7add93cfc6393c57ec0b3c17d6b44",
+		"126735fcc320d25a",
+		"ca40d7446e545ffaed3bd12a740a659ffbbb3ceab7",
+		"cb8920f87a6c75cff39627b56e3ed197c552d295a7cfc46afc253b4652b1af3795b124ab6e",
+	},
+}
+
+func TestEAX(t *testing.T) {
+	for _, impl := range impls {
+		t.Logf("Testing implementation: %v\n", impl.name)
+		for i, vec := range eaxVectors {
+			key, err := hex.DecodeString(vec.key[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			nonce, err := hex.DecodeString(vec.nonce[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			header, err := hex.DecodeString(vec.header[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			pt, err := hex.DecodeString(vec.plaintext[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			ct, err := hex.DecodeString(vec.ciphertext[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			e, err := NewEAX(impl.ctor(key), len(nonce), 16)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			dst := e.Seal(nil, nonce, pt, header)
+			assertEqual(t, i, ct, dst)
+
+			dst, err = e.Open(nil, nonce, ct, header)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertEqual(t, i, pt, dst)
+
+			// Truncated tags.
+			for _, tagSize := range []int{4, 8, 12} {
+				e, err = NewEAX(impl.ctor(key), len(nonce), tagSize)
+				if err != nil {
+					t.Fatal(err)
+				}
+				truncated := ct[:len(ct)-16+tagSize]
+
+				dst = e.Seal(nil, nonce, pt, header)
+				assertEqual(t, i, truncated, dst)
+
+				dst, err = e.Open(nil, nonce, truncated, header)
+				if err != nil {
+					t.Fatal(err)
+				}
+				assertEqual(t, i, pt, dst)
+			}
+
+			ct[0] ^= 0x80
+			if _, err = e.Open(nil, nonce, ct[:len(ct)-4], header); err == nil {
+				t.Fatalf("[%d] Open succeeded with corrupted ciphertext", i)
+			}
+		}
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package bsaes
+
+import (
+	"errors"
+
+	"git.schwanenlied.me/yawning/bsaes.git/internal/modes"
+)
+
+// EME2 is an EME2-AES instance, implemented from the IEEE 1619.2
+// description.  It has not been validated against the standard's test
+// vectors, so interoperability with other implementations is unconfirmed.
+type EME2 interface {
+	// Encrypt encrypts src with the tweak, and places the resulting output
+	// in dst.  src must be at least BlockSize bytes long, and may be any
+	// length beyond that.  The tweak may be of any length.
+	Encrypt(dst, src, tweak []byte)
+
+	// Decrypt decrypts src with the tweak, and places the resulting output
+	// in dst.
+	Decrypt(dst, src, tweak []byte)
+}
+
+// NewEME2 creates and returns a new EME2-AES instance.  The key is the 48, 56,
+// or 64 byte concatenation of the 16 byte tweak key, the 16 byte ECB mask key,
+// and the AES-128, AES-192, or AES-256 key.  Both of the ECB layers are
+// processed with the bulk interface.
+func NewEME2(key []byte) (EME2, error) {
+	switch len(key) {
+	case 48, 56, 64:
+	default:
+		return nil, errors.New("bsaes: invalid EME2-AES key size")
+	}
+
+	e, err := modes.NewEME2(mustNewCipher, key)
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
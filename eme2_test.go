@@ -0,0 +1,213 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package bsaes
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"git.schwanenlied.me/yawning/bsaes.git/internal/modes"
+)
+
+// The IEEE 1619.2 test vectors could not be obtained, so these vectors were
+// generated with a separately written implementation of EME2-AES, built on a
+// reference AES, that shares no code with this package.  They cover all
+// three AES key sizes, empty, partial, and multi-block tweaks, block aligned
+// and unaligned data units, and data units of 130, 256, and 385 blocks that
+// exercise the remixing every 128 blocks (the long vectors are checked by
+// the SHA-256 digest of the ciphertext of the bytes 0x00, 0x01, ...).
+
+var eme2Vectors = []struct {
+	key        string
+	tweak      string
+	plaintext  string
+	ciphertext string
+}{
+	{
+		"578f061dd6c8a28a86039e2f0e4e617a254a10cc454e35dd3e35baeed2423e3a60e98db591090fd66bb4e6a452068e27",
+		"",
+		"8eeeee0f7f5f7428ddda98b184f39d3e",
+		"3eee82f95de59f71baf87fa68e220816",
+	},
+	{
+		"ffeacd636dc8e6d619ca22daf67f3553668a3bf4eb3be63900f3d5adf24cabcf4f1f2e1de988574ac881656ee3200e38",
+		"f805b4da2f7a4f37545bcaee1bea04ba",
+		"ed81dd25464dabe492a7db3bc5c9ea3c",
+		"ba6b1aa8c67db9d5489363a5de7e14c0",
+	},
+	{
+		"fa3b38d355ecd0ed857eed24d095252181dd324b749b8e13f9facd6a6daa7e140ac14b7caa6c8c4fd07ff96550164336",
+		"8c13ece071",
+		"1cbdc5c94bcb57277a76c2baf9bb3bdd8c",
+		"dec0d0be06fd3c520eba4226bb0b8158c5",
+	},
+	{
+		"51ebf5ddd4766ce988df55a46599a904ba76a26f15d2cf3658dd03fc184c8db4fa96eef3b6ab674e9a1675ade2e2de81bb202152c38588c5fab067f2f9128d3f",
+		"16661658b7c60673d0959c199711e9b147c50f75c2224cd092ef605cc25a94d3",
+		"d6b9948b37dafb0439c7a8bc17e4fd901c5f4b9eea364d832b8192c06afd0aca",
+		"0c68c70a6791e25aa5a690107028a7a25b15379da863ee0ca26775e4840b3351",
+	},
+	{
+		"aaf2bba149db5e43d0c192698a5a128f38f08ce8759f20917b7279b2450590481e0cb79fc8fde80ca234b92dd4f9af591031c69b910e29d2",
+		"8f74caaf105b3d0753dba2f42b87fa0fabaedd35",
+		"a4dea200f2e3f58c316d1f6b8bdeb9993ffcfc2f06da2fa37801e860586ffa5d78d850522e026bacfdf33872b037df",
+		"eda359a5ecda7546a9b89ce8625aac0cefb7b5ab446cb805d774c24acc6954e73a311b58884908951c6833a95a198d",
+	},
+	{
+		"ba000ba30aeacdeab8c3610c66761617d50e04d2cf66ca586b9c5896fa7348e17dad831515b693918dfd77de1d8547c0b8737d7fcc7ca757f8a22e14033ea48c",
+		"",
+		"2deeff2ac87ba7b0c3fdd4c9ad0f6484d179ccd81224b84e4a2f551d84f1fe3a9fdc1ff18c061cd29fd8b48ec529eceb47e21b7e3dd486d33362ae55200fe182583d2d1c8ee378202e5116c9113814d2098d8afef4b35b351434f23654a87e41bdfe888b",
+		"9269bf87abb1afb35e53d8a24c3cea444ff715c45086a77fcd75135be575a83742a4a0b3fd7381953ecffef28ae1ffb49fc51ef80b02f7e382fa1719d3164765a07287797c03cf5e2c1f0252dcf58295bea12b428da2e2ea99a8930b13883b07d0eeccc9",
+	},
+	{
+		"85e27e893ceb00a4918ce4b4dbbb71f6acc4855fdf11b328e6097d7a714eb3192cc8f369f0ccd56a2a8e3de40c05489f",
+		"9e3ef0e84411823334399ca84b1a0449",
+		"63882baec15cc62e0cd838574e017729305c4fcacd4e547b40b2e4c7501d3398f49f176716efcfb40e9ce9a4100fa6e3fd6c5c1c4db6fa15a72ca1ca9ae25baeae28ee9a868225d1f8bba28f01b5c0c3eaec53386671d86ea1a261d9d82cf952bce0689c1253e1d2c97bcc9391f023cf73549833108578e97f4af920c0689ecf4ac55c3f3afe238faf07893f8ccb194519991c8ea91395bb44ee301f49e1b6d6168aaadb369643174c5ce81b97631742852647aa8514a52c9b824b4e671dfdf2c8947c1f489f7b619ca73e7bcb2e01de1a194092a206c5370faf07f53bea7616fb83047894cfad24784e33778ef71b97afcbafe42bd987c6701b28baddbfb5f6152135c31d527e001e28838f00afcf0ab784366e6041d92eef48a92e2b4e39aa76d1c56287914fcbc96e04687c8179ec0377c26270ac10306e07726824feaad9101259c30718edad861200d33d750467ec09d534187e591a355bf45b922744fe49ebf39d47aab000d07746123e63554d69566ae0a68165854d908d5e277d9aa3d23f75c4b704f50c0260f749d8cdd6b9e5f8d49a521c47e0798bc07dca8d84adde53dd8500e4cd842e8ab0992c3c3063e062954553d12925ed3974c91ea2f59c2e45990496f00ed5f39573d8f1337a6957912a1b481c10da6a792ce2d42a079f8261c40061732cfd914b36ed84308eeb192837151c6cfa0fdbeae1098a873955",
+		"99b3f71b4e3e588be75bce16d134be512db5b137f215a4006869e94e095ba63ec88c6c843c45f542a85bf84f2a7d1d4bc114eccdcbabfb238e968b93f1e6f1313e5e7bdcbce0487254bece7735828409885c3779104dbc1ccb8f03ba12dec79391c3df4d97ed230fa4b47dbd611888c57ff881ad21a52621a4e590befa344d57d97a1517394f89734651bd2f93dac7c3513bca23d85138eae54bd418fcd39df251cb408834d51c820080a3c5c72a3fb7aeda5a6ae0f3e77f3596480ed71ebc8229b4804bfa8f575e640c228d116781cc3019aca0d1251dea4d4178c2f78300378dfd9b48ac48a484a3c3e8dbb4090a98eb4145945715c1ca94153ff165015fc6fe68e3c40fb249ab5531052d38a04df69ddef3da3c7e094a6d533b50549faa6345e520e4f9d1e389ee8c2cd960320a915b6fbbb9db6daa119dc8b93696dffa9979798b51b25c43c6b562e24f7f9d61045f2cdd2e984d51475398d814af2311d720d0c0054f11b31d0bb90bc91149e70fcc1b47b57b621bdf7534a512e5b4d3b35dab450665c8cc5cb7995331cb98a04b5c2553deddf9afd6ffccb46ef7e6c1ac950a7135b67b6779b909f5a7fae6f23b83624f32886ac504660a01383208f622479ee5716edef3ecbeca0b3705b3a1d72c5a76ebbba8c0d0c442bf6ec08ec4757bdaa5d0031097b7b43146119a363027253ba6ecf55cbcdbe1c0c8ac176d2608",
+	},
+}
+
+// The long vectors exercise the mask re-derivation every 128 blocks, with
+// the plaintext being the byte sequence 0x00, 0x01, ..., and are checked
+// against the SHA-256 digest of the ciphertext.
+var eme2LongVectors = []struct {
+	key    string
+	tweak  string
+	length int
+	digest string
+}{
+	{
+		"f87a08c5f8d1e410e1d755cd7fd007061e502734b60cd12f3c15d765720db8c0f10c17fefdd9399ad646ff60d9f8f24b",
+		"2409e76ccbb49144dbfb5e62dfbdf51f",
+		4096 + 5,
+		"398fc0c3e5ce846434d7a64855dcf392dc8a7141faa92aeb281304b0b74d6bcb",
+	},
+	{
+		"12b765dc873f116c2c7e658ff4fc05641b72bc06b8eeaa420a96dd7d9a42ea66b8d8d72dda784d8aeb39328e6d55194ec30bab3d196e4069150be58f4e51fc0d",
+		"07022d1187f201",
+		130 * 16,
+		"e7ccd0aedd2a5261098ecc21dbd23af08db4ed4af9a2914b97113c6fcce99a03",
+	},
+	{
+		"030a11181f262d343b424950575e656c737a81888f969da4abb2b9c0c7ced5dce3eaf1f8ff060d141b222930373e454c535a61686f767d84",
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f20",
+		385*16 + 7,
+		"873918df88b552587c8464dc0ffebe94c44f280e5fd31cbc5a9afd6c36ae605a",
+	},
+}
+
+func TestEME2(t *testing.T) {
+	for _, impl := range impls {
+		t.Logf("Testing implementation: %v\n", impl.name)
+		for i, vec := range eme2Vectors {
+			key, err := hex.DecodeString(vec.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tweak, err := hex.DecodeString(vec.tweak)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pt, err := hex.DecodeString(vec.plaintext)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ct, err := hex.DecodeString(vec.ciphertext)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			e, err := modes.NewEME2(impl.ctor, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			dst := make([]byte, len(pt))
+			e.Encrypt(dst, pt, tweak)
+			assertEqual(t, i, ct, dst)
+
+			e.Decrypt(dst, ct, tweak)
+			assertEqual(t, i, pt, dst)
+
+			// In-place.
+			copy(dst, pt)
+			e.Encrypt(dst, dst, tweak)
+			assertEqual(t, i, ct, dst)
+			e.Decrypt(dst, dst, tweak)
+			assertEqual(t, i, pt, dst)
+
+			// Changing the tweak changes the ciphertext.
+			e.Encrypt(dst, pt, append(tweak, 0))
+			if bytes.Equal(dst[:BlockSize], ct[:BlockSize]) {
+				t.Fatalf("[%d] Encrypt ignored the tweak", i)
+			}
+		}
+
+		for i, vec := range eme2LongVectors {
+			key, err := hex.DecodeString(vec.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tweak, err := hex.DecodeString(vec.tweak)
+			if err != nil {
+				t.Fatal(err)
+			}
+			digest, err := hex.DecodeString(vec.digest)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pt := make([]byte, vec.length)
+			for j := range pt {
+				pt[j] = byte(j)
+			}
+
+			e, err := modes.NewEME2(impl.ctor, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			dst := make([]byte, len(pt))
+			e.Encrypt(dst, pt, tweak)
+			d := sha256.Sum256(dst)
+			assertEqual(t, i, digest, d[:])
+
+			e.Decrypt(dst, dst, tweak)
+			assertEqual(t, i, pt, dst)
+		}
+	}
+}
+
+func TestEME2KeySize(t *testing.T) {
+	for _, sz := range []int{0, 16, 32, 47, 49, 65} {
+		if _, err := NewEME2(make([]byte, sz)); err == nil {
+			t.Fatalf("NewEME2 accepted a %d byte key", sz)
+		}
+	}
+	for _, sz := range []int{48, 56, 64} {
+		if _, err := NewEME2(make([]byte, sz)); err != nil {
+			t.Fatalf("NewEME2 rejected a %d byte key: %v", sz, err)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package bsaes
+
+import (
+	"crypto/cipher"
+
+	"git.schwanenlied.me/yawning/bsaes.git/internal/modes"
+)
+
+// NewCommittingGCM returns a key-committing AES-GCM cipher.AEAD instance,
+// with a 12 byte nonce.  The key argument should be the AES key, either 16,
+// 24, or 32 bytes.
+//
+// This is the CTX construction of Chan and Rogaway ("On Committing
+// Authenticated-Encryption", ESORICS 2022) over AES-GCM, with SHA-256 as the
+// hash.  The ciphertext is the AES-GCM ciphertext C, followed by
+//
+//	T* = SHA-256(BE64(len(K)) || K || BE64(len(N)) || N || BE64(len(A)) || A || T)
+//
+// in place of the AES-GCM tag T, for 32 bytes of overhead.  Opening
+// recomputes T from C, and rejects the ciphertext unless T* matches.
+//
+// As SHA-256 is collision resistant, T* commits to the key, nonce, and
+// additional data, which in turn fix the only plaintext that C decrypts to,
+// so a ciphertext can not be opened under more than one key (CMT-4).  This
+// prevents partitioning oracle attacks that rely on multi-key collisions.
+// The output is not interoperable with plain AES-GCM.
+func NewCommittingGCM(key []byte) (cipher.AEAD, error) {
+	blk, err := NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return modes.NewCommittingGCM(blk, key)
+}
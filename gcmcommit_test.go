@@ -0,0 +1,201 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package bsaes
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+
+	"git.schwanenlied.me/yawning/bsaes.git/ghash"
+	"git.schwanenlied.me/yawning/bsaes.git/internal/modes"
+)
+
+func TestCommittingGCM(t *testing.T) {
+	for _, impl := range impls {
+		t.Logf("Testing implementation: %v\n", impl.name)
+		for i, vec := range gcmCommitTestKeys {
+			key, err := hex.DecodeString(vec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			nonce := make([]byte, 12)
+			ad := []byte("additional data")
+			pt := []byte("The quick brown fox jumps over the lazy dog.")
+
+			c, err := modes.NewCommittingGCM(impl.ctor(key), key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ct := c.Seal(nil, nonce, pt, ad)
+
+			// C is ordinary AES-GCM, with the tag T replaced by
+			// T* = SHA-256(BE64(len(K)) || K || BE64(len(N)) || N ||
+			//   BE64(len(A)) || A || T)
+			blk, _ := aes.NewCipher(key)
+			g, _ := cipher.NewGCM(blk)
+			gcmCt := g.Seal(nil, nonce, pt, ad)
+			assertEqual(t, i, gcmCt[:len(pt)], ct[:len(pt)])
+
+			var l [8]byte
+			h := sha256.New()
+			for _, v := range [][]byte{key, nonce, ad} {
+				binary.BigEndian.PutUint64(l[:], uint64(len(v)))
+				h.Write(l[:])
+				h.Write(v)
+			}
+			h.Write(gcmCt[len(pt):])
+			assertEqual(t, i, h.Sum(nil), ct[len(pt):])
+
+			dst, err := c.Open(nil, nonce, ct, ad)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertEqual(t, i, pt, dst)
+
+			ct[len(ct)-1] ^= 0x80
+			if _, err = c.Open(nil, nonce, ct, ad); err == nil {
+				t.Fatalf("[%d] Open succeeded with corrupted tag", i)
+			}
+			ct[len(ct)-1] ^= 0x80
+			ct[0] ^= 0x80
+			if _, err = c.Open(nil, nonce, ct, ad); err == nil {
+				t.Fatalf("[%d] Open succeeded with corrupted ciphertext", i)
+			}
+		}
+	}
+}
+
+var gcmCommitTestKeys = []string{
+	"000102030405060708090a0b0c0d0e0f",
+	"000102030405060708090a0b0c0d0e0f1011121314151617",
+	"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+}
+
+func TestCommittingGCMMultiKeyCollision(t *testing.T) {
+	k1, _ := hex.DecodeString("00112233445566778899aabbccddeeff")
+	k2, _ := hex.DecodeString("ffeeddccbbaa99887766554433221100")
+	nonce := make([]byte, 12)
+
+	// Build a single block AES-GCM ciphertext C || T that is valid under
+	// both keys (Len, Grubbs, and Ristenpart), by solving
+	//
+	//   C * H_1^2 + L * H_1 + E_K1(J0) = C * H_2^2 + L * H_2 + E_K2(J0)
+	//
+	// for C, where L is the length block.
+	var h1, h2, p1, p2, l [16]byte
+	var j0 [16]byte
+	copy(j0[:], nonce)
+	j0[15] = 1
+	for _, v := range []struct {
+		key  []byte
+		h, p *[16]byte
+	}{{k1, &h1, &p1}, {k2, &h2, &p2}} {
+		blk, _ := aes.NewCipher(v.key)
+		blk.Encrypt(v.h[:], v.h[:])
+		blk.Encrypt(v.p[:], j0[:])
+	}
+	binary.BigEndian.PutUint64(l[8:], 128)
+
+	var hSum, hSqSum, num, c, tag [16]byte
+	xorBytes(hSum[:], h1[:], h2[:])
+	h1Sq, h2Sq := gfMul(&h1, &h1), gfMul(&h2, &h2)
+	xorBytes(hSqSum[:], h1Sq[:], h2Sq[:])
+	num = gfMul(&l, &hSum)
+	xorBytes(num[:], num[:], p1[:])
+	xorBytes(num[:], num[:], p2[:])
+	inv := gfInv(&hSqSum)
+	c = gfMul(&num, &inv)
+	tag = gfMul(&c, &h1Sq)
+	lh1 := gfMul(&l, &h1)
+	xorBytes(tag[:], tag[:], lh1[:])
+	xorBytes(tag[:], tag[:], p1[:])
+	collision := append(c[:], tag[:]...)
+
+	// Plain AES-GCM accepts the ciphertext under both keys.
+	var pts [2][]byte
+	for i, key := range [][]byte{k1, k2} {
+		blk, _ := aes.NewCipher(key)
+		g, _ := cipher.NewGCM(blk)
+		pt, err := g.Open(nil, nonce, collision, nil)
+		if err != nil {
+			t.Fatalf("failed to construct a multi-key collision: %v", err)
+		}
+		pts[i] = pt
+	}
+	if bytes.Equal(pts[0], pts[1]) {
+		t.Fatalf("multi-key collision decrypted to the same plaintext")
+	}
+
+	for _, impl := range impls {
+		t.Logf("Testing implementation: %v\n", impl.name)
+
+		c1, err := modes.NewCommittingGCM(impl.ctor(k1), k1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c2, err := modes.NewCommittingGCM(impl.ctor(k2), k2)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Committing to K1 yields the colliding AES-GCM ciphertext, which
+		// is rejected under K2.
+		ct := c1.Seal(nil, nonce, pts[0], nil)
+		assertEqual(t, 0, collision[:16], ct[:16])
+		if _, err = c1.Open(nil, nonce, ct, nil); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = c2.Open(nil, nonce, ct, nil); err == nil {
+			t.Fatalf("Open succeeded under the wrong key")
+		}
+	}
+}
+
+// gfMul returns the product of x and y in the GHASH field.
+func gfMul(x, y *[16]byte) [16]byte {
+	var z [16]byte
+	ghash.Ghash(&z, y, x[:])
+	return z
+}
+
+// gfInv returns the inverse of x in the GHASH field, x^(2^128 - 2).
+func gfInv(x *[16]byte) [16]byte {
+	z := [16]byte{0x80} // 1
+	s := *x
+	for i := 1; i < 128; i++ {
+		s = gfMul(&s, &s)
+		z = gfMul(&z, &s)
+	}
+	return z
+}
+
+func xorBytes(dst, a, b []byte) {
+	for i, v := range a {
+		dst[i] = v ^ b[i]
+	}
+}
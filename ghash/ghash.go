@@ -21,7 +21,8 @@
 // CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
 // SOFTWARE.
 
-// Package ghash is a constant time 64 bit optimized GHASH implementation.
+// Package ghash is a constant time 64 bit optimized GHASH and POLYVAL
+// implementation, along with the underlying GF(2^128) multiplier.
 package ghash
 
 import "encoding/binary"
@@ -57,19 +58,94 @@ func rev64(x uint64) uint64 {
 	return (x << 32) | (x >> 32)
 }
 
+// Mul calculates the product of x and y in GF(2^128) with the polynomial
+// x^128 + x^7 + x^2 + x + 1, and stores the result in z.  Unlike with GHASH,
+// the elements are the big endian encodings of the polynomials, with the most
+// significant bit being the coefficient of x^127 (eg: MGM, RFC 9058).
+func Mul(z, x, y *[blockSize]byte) {
+	x1 := binary.BigEndian.Uint64(x[:])
+	x0 := binary.BigEndian.Uint64(x[8:])
+	y1 := binary.BigEndian.Uint64(y[:])
+	y0 := binary.BigEndian.Uint64(y[8:])
+
+	x0r := rev64(x0)
+	x1r := rev64(x1)
+	x2 := x0 ^ x1
+	x2r := x0r ^ x1r
+	y0r := rev64(y0)
+	y1r := rev64(y1)
+	y2 := y0 ^ y1
+	y2r := y0r ^ y1r
+
+	z0 := bmul64(x0, y0)
+	z1 := bmul64(x1, y1)
+	z2 := bmul64(x2, y2)
+	z0h := bmul64(x0r, y0r)
+	z1h := bmul64(x1r, y1r)
+	z2h := bmul64(x2r, y2r)
+	z2 ^= z0 ^ z1
+	z2h ^= z0h ^ z1h
+	z0h = rev64(z0h) >> 1
+	z1h = rev64(z1h) >> 1
+	z2h = rev64(z2h) >> 1
+
+	v0 := z0
+	v1 := z0h ^ z2
+	v2 := z1 ^ z2h
+	v3 := z1h
+
+	// x^128 = x^7 + x^2 + x + 1, first for the top 64 bits (v3), and then
+	// for the next 64 bits (v2) which picked up the overflow from v3.
+	v2 ^= (v3 >> 63) ^ (v3 >> 62) ^ (v3 >> 57)
+	v1 ^= v3 ^ (v3 << 1) ^ (v3 << 2) ^ (v3 << 7)
+	v1 ^= (v2 >> 63) ^ (v2 >> 62) ^ (v2 >> 57)
+	v0 ^= v2 ^ (v2 << 1) ^ (v2 << 2) ^ (v2 << 7)
+
+	binary.BigEndian.PutUint64(z[:], v1)
+	binary.BigEndian.PutUint64(z[8:], v0)
+}
+
 // Ghash calculates the GHASH of data, with key h, and input y, and stores the
 // resulting digest in y.
 func Ghash(y, h *[blockSize]byte, data []byte) {
+	y1 := binary.BigEndian.Uint64(y[:])
+	y0 := binary.BigEndian.Uint64(y[8:])
+	h1 := binary.BigEndian.Uint64(h[:])
+	h0 := binary.BigEndian.Uint64(h[8:])
+
+	y1, y0 = ghashBlocks(y1, y0, h1, h0, data, false)
+
+	binary.BigEndian.PutUint64(y[:], y1)
+	binary.BigEndian.PutUint64(y[8:], y0)
+}
+
+// Polyval calculates the POLYVAL (RFC 8452) of data, with key h, and input y,
+// and stores the resulting digest in y.
+func Polyval(y, h *[blockSize]byte, data []byte) {
+	// POLYVAL(H, X_1, ..., X_n) = ByteReverse(GHASH(mulX_GHASH(
+	//   ByteReverse(H)), ByteReverse(X_1), ..., ByteReverse(X_n)))
+	y1 := binary.LittleEndian.Uint64(y[8:])
+	y0 := binary.LittleEndian.Uint64(y[:])
+	h1 := binary.LittleEndian.Uint64(h[8:])
+	h0 := binary.LittleEndian.Uint64(h[:])
+
+	carry := h0 & 1
+	h0 = (h0 >> 1) | (h1 << 63)
+	h1 = (h1 >> 1) ^ (0xe100000000000000 & -carry)
+
+	y1, y0 = ghashBlocks(y1, y0, h1, h0, data, true)
+
+	binary.LittleEndian.PutUint64(y[8:], y1)
+	binary.LittleEndian.PutUint64(y[:], y0)
+}
+
+func ghashBlocks(y1, y0, h1, h0 uint64, data []byte, byteReverse bool) (uint64, uint64) {
 	var tmp [blockSize]byte
 	var src []byte
 
 	buf := data
 	l := len(buf)
 
-	y1 := binary.BigEndian.Uint64(y[:])
-	y0 := binary.BigEndian.Uint64(y[8:])
-	h1 := binary.BigEndian.Uint64(h[:])
-	h0 := binary.BigEndian.Uint64(h[8:])
 	h0r := rev64(h0)
 	h1r := rev64(h1)
 	h2 := h0 ^ h1
@@ -85,8 +161,13 @@ func Ghash(y, h *[blockSize]byte, data []byte) {
 			src = tmp[:]
 			l = 0
 		}
-		y1 ^= binary.BigEndian.Uint64(src)
-		y0 ^= binary.BigEndian.Uint64(src[8:])
+		if byteReverse {
+			y1 ^= binary.LittleEndian.Uint64(src[8:])
+			y0 ^= binary.LittleEndian.Uint64(src)
+		} else {
+			y1 ^= binary.BigEndian.Uint64(src)
+			y0 ^= binary.BigEndian.Uint64(src[8:])
+		}
 
 		y0r := rev64(y0)
 		y1r := rev64(y1)
@@ -124,6 +205,5 @@ func Ghash(y, h *[blockSize]byte, data []byte) {
 		y1 = v3
 	}
 
-	binary.BigEndian.PutUint64(y[:], y1)
-	binary.BigEndian.PutUint64(y[8:], y0)
+	return y1, y0
 }
@@ -116,6 +116,82 @@ func TestGHASH(t *testing.T) {
 	}
 }
 
+func TestPOLYVAL(t *testing.T) {
+	// The test vector is taken from RFC 8452, Appendix A.
+	hh, _ := hex.DecodeString("25629347589242761d31f826ba4b757b")
+	x, _ := hex.DecodeString("4f4f95668c83dfb6401762bb2d01a262d1a24ddd2721d006bbe45f20d3c9f362")
+	yy, _ := hex.DecodeString("f7a3b47b846119fae5b7866cf5e5b77e")
+
+	var h, y [blockSize]byte
+	copy(h[:], hh)
+
+	Polyval(&y, &h, x)
+	assertEqual(t, 0, yy, y[:])
+
+	// Incremental processing must match.
+	var y2 [blockSize]byte
+	Polyval(&y2, &h, x[:blockSize])
+	Polyval(&y2, &h, x[blockSize:])
+	assertEqual(t, 1, yy, y2[:])
+}
+
+func TestMul(t *testing.T) {
+	// x^127 * x = x^7 + x^2 + x + 1, and the rest were generated with a
+	// schoolbook multiply and reduce.
+	vectors := []struct {
+		x string
+		y string
+		z string
+	}{
+		{
+			"80000000000000000000000000000000",
+			"00000000000000000000000000000002",
+			"00000000000000000000000000000087",
+		},
+		{
+			"5bc8fbbcbde5c0994164d8399f767c45",
+			"00000000000000000000000000000001",
+			"5bc8fbbcbde5c0994164d8399f767c45",
+		},
+		{
+			"5bc8fbbcbde5c0994164d8399f767c45",
+			"d76d4330f1446beab0c11fdecb91ce37",
+			"cea941e637a187886f0006cd5667c362",
+		},
+		{
+			"87b0b125ec1d7da0a6eb8c9ebd69fe29",
+			"c6a5387777330bdbd7210dff076ce2ef",
+			"5c7ac0ac09ccbc14ff7dbd8e3bd402fd",
+		},
+		{
+			"0d464138a62332553fc1ea36f17fd374",
+			"5f2dd97f1cfb10f62827688de6a16a3b",
+			"729e488f0a055824e464123c48ef8257",
+		},
+		{
+			"617959ce3f1f65a8de5271007814e8a2",
+			"3fd4235992edcf451a1afe878b33e968",
+			"1da90e538cae04f221acdc9f53f38379",
+		},
+	}
+
+	for i, vec := range vectors {
+		var x, y, z [blockSize]byte
+		xx, _ := hex.DecodeString(vec.x)
+		yy, _ := hex.DecodeString(vec.y)
+		zz, _ := hex.DecodeString(vec.z)
+		copy(x[:], xx)
+		copy(y[:], yy)
+
+		Mul(&z, &x, &y)
+		assertEqual(t, i, zz, z[:])
+
+		// Multiplication is commutative, and z may alias the inputs.
+		Mul(&y, &y, &x)
+		assertEqual(t, i, zz, y[:])
+	}
+}
+
 func assertEqual(t *testing.T, idx int, expected, actual []byte) {
 	if !bytes.Equal(expected, actual) {
 		for i, v := range actual {
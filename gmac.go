@@ -0,0 +1,48 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package bsaes
+
+import (
+	"crypto/cipher"
+	"hash"
+
+	"git.schwanenlied.me/yawning/bsaes.git/internal/modes"
+)
+
+// NewGMAC returns a new GMAC (SP 800-38D) hash.Hash, keyed by the
+// cipher.Block b, and the nonce, which should be 12 bytes.  The digest is the
+// tag that GCM would produce when sealing an empty plaintext with the data
+// written as the additional data, without the GCM instance or the
+// allocation.  As with GCM, a nonce must never be reused with the same key.
+func NewGMAC(b cipher.Block, nonce []byte) (hash.Hash, error) {
+	return modes.NewGMAC(b, nonce)
+}
+
+// NewGMACAEAD returns a new GMAC (SP 800-38D) cipher.AEAD, keyed by the
+// cipher.Block b, with a nonceSize byte nonce, which should be 12.  Only the
+// additional data is authenticated: Seal panics if the plaintext is not
+// empty, and appends the tag that GCM would produce, and Open only accepts a
+// ciphertext that is exactly the tag.
+func NewGMACAEAD(b cipher.Block, nonceSize int) (cipher.AEAD, error) {
+	return modes.NewGMACAEAD(b, nonceSize)
+}
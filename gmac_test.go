@@ -0,0 +1,207 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package bsaes
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"testing"
+)
+
+// The test vectors are the empty plaintext entries from the GCM vectors, and
+// the first PTlen = 0, AADlen = 128 entry of the NIST CAVP GCM test vectors
+// (gcmEncryptExtIV128.rsp).
+
+var gmacVectors = []struct {
+	key   string
+	nonce string
+	ad    string
+	tag   string
+}{
+	{
+		"77be63708971c4e240d1cb79e8d77feb",
+		"e0e00f19fed7ba0136a797f3",
+		"7a43ec1d9c0a5a78a0b16533a6213cab",
+		"209fcc8d3675ed938e9c7166709dd946",
+	},
+}
+
+func TestGMAC(t *testing.T) {
+	vectors := gmacVectors
+	for _, vec := range gcmVectors {
+		if vec.p == "" {
+			vectors = append(vectors, struct {
+				key   string
+				nonce string
+				ad    string
+				tag   string
+			}{vec.k, vec.iv, vec.a, vec.t})
+		}
+	}
+
+	for _, impl := range impls {
+		t.Logf("Testing implementation: %v\n", impl.name)
+		for i, vec := range vectors {
+			key, err := hex.DecodeString(vec.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			nonce, err := hex.DecodeString(vec.nonce)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ad, err := hex.DecodeString(vec.ad)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tag, err := hex.DecodeString(vec.tag)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			m, err := NewGMAC(impl.ctor(key), nonce)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m.Write(ad)
+			assertEqual(t, i, tag, m.Sum(nil))
+
+			// Sum does not change the state.
+			assertEqual(t, i, tag, m.Sum(nil))
+
+			m.Reset()
+			m.Write(ad)
+			assertEqual(t, i, tag, m.Sum(nil))
+		}
+
+		// Compare against GCM with an empty plaintext, with the data
+		// written in irregularly sized pieces, and the nonces from the
+		// GCM vectors (including the ones that are not 96 bits).
+		for i, vec := range gcmVectors {
+			key, err := hex.DecodeString(vec.k)
+			if err != nil {
+				t.Fatal(err)
+			}
+			nonce, err := hex.DecodeString(vec.iv)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			blk, err := aes.NewCipher(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			g, err := cipher.NewGCMWithNonceSize(blk, len(nonce))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			m, err := NewGMAC(impl.ctor(key), nonce)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var ad []byte
+			for sz := 0; sz < 64; sz++ {
+				ad = append(ad, byte(sz))
+
+				m.Reset()
+				for off, step := 0, 1; off < len(ad); off, step = off+step, step+2 {
+					end := off + step
+					if end > len(ad) {
+						end = len(ad)
+					}
+					m.Write(ad[off:end])
+				}
+				assertEqual(t, i, g.Seal(nil, nonce, nil, ad), m.Sum(nil))
+			}
+		}
+	}
+}
+
+func TestGMACAEAD(t *testing.T) {
+	for _, impl := range impls {
+		t.Logf("Testing implementation: %v\n", impl.name)
+		for i, vec := range gcmVectors {
+			key, err := hex.DecodeString(vec.k)
+			if err != nil {
+				t.Fatal(err)
+			}
+			nonce, err := hex.DecodeString(vec.iv)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ad, err := hex.DecodeString(vec.a)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			blk, err := aes.NewCipher(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			g, err := cipher.NewGCMWithNonceSize(blk, len(nonce))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			m, err := NewGMACAEAD(impl.ctor(key), len(nonce))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The tag matches GCM with an empty plaintext.
+			tag := m.Seal(nil, nonce, nil, ad)
+			assertEqual(t, i, g.Seal(nil, nonce, nil, ad), tag)
+			if vec.p == "" {
+				expected, _ := hex.DecodeString(vec.t)
+				assertEqual(t, i, expected, tag)
+			}
+
+			dst, err := m.Open([]byte("prefix"), nonce, tag, ad)
+			if err != nil {
+				t.Fatalf("[%d] Open failed: %v", i, err)
+			}
+			assertEqual(t, i, []byte("prefix"), dst)
+
+			tag[0] ^= 0x80
+			if _, err = m.Open(nil, nonce, tag, ad); err == nil {
+				t.Fatalf("[%d] Open succeeded with a corrupted tag", i)
+			}
+			tag[0] ^= 0x80
+			if _, err = m.Open(nil, nonce, append(tag, 0), ad); err == nil {
+				t.Fatalf("[%d] Open succeeded with a non-empty ciphertext", i)
+			}
+
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Fatalf("[%d] Seal accepted a non-empty plaintext", i)
+					}
+				}()
+				m.Seal(nil, nonce, []byte{0}, ad)
+			}()
+		}
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package bsaes
+
+import (
+	"crypto/cipher"
+
+	"git.schwanenlied.me/yawning/bsaes.git/internal/modes"
+)
+
+// HCTR2 is a HCTR2 length-preserving tweakable wide-block cipher instance.
+type HCTR2 interface {
+	// Encrypt encrypts src with the tweak, and places the resulting output
+	// in dst.  src must be at least BlockSize bytes long, and may be any
+	// length beyond that.  The tweak may be of any length.
+	Encrypt(dst, src, tweak []byte)
+
+	// Decrypt decrypts src with the tweak, and places the resulting output
+	// in dst.
+	Decrypt(dst, src, tweak []byte)
+}
+
+// NewHCTR2 creates and returns a new HCTR2 instance, using the cipher.Block
+// b, which is expected to be obtained from NewCipher.  The XCTR keystream
+// is generated with the bulk interface, and POLYVAL is constant time.
+func NewHCTR2(b cipher.Block) (HCTR2, error) {
+	x, err := modes.NewHCTR2(b)
+	if err != nil {
+		return nil, err
+	}
+	return x, nil
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package bsaes
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// The HCTR2 paper and Linux kernel (aes_hctr2_tv_template) test vectors
+// could not be obtained, so these vectors were generated with a separately
+// written implementation of the HCTR2 specification that shares no code with
+// this package, built on a reference AES and a POLYVAL checked against
+// RFC 8452.  They cover both key sizes, empty, short, and long tweaks, and
+// block aligned and unaligned messages.
+
+var hctr2Vectors = []struct {
+	key        string
+	tweak      string
+	plaintext  string
+	ciphertext string
+}{
+	{
+		"bf0390a2f221b1d255d887b8fa3ef061",
+		"",
+		"ecc942f916dc4a951ddcb50c1fe8a92a",
+		"d19ee13cee79fffdb6d37eb3ac65caa8",
+	},
+	{
+		"d0598daf35e2fe3015775e669163b689",
+		"17ca151a55b6a5afaa5d03aaf4e73eba5fc745c42d05f167c62f142e8cccac15",
+		"09a6013920e1cc9f22d0c3f11e462a5f",
+		"6c4dccbd02e351963a7231e27aeda2e5",
+	},
+	{
+		"515c7398c1647828b3bbd41a631ffad6",
+		"a92a6697e1542b95b399362ab5f6ea3f55dc93119743cd2fa91deeca289b4bf9",
+		"dc7b5d935baea4842cc8bd2750e1d6cd65",
+		"60e33453bba2f13e3f1676e6f424d8008e",
+	},
+	{
+		"438aa356d64b5493b9244cf88c9331e0b24290447078200baee5ba895ede37d0",
+		"a6c510084e693448dbcb866c3790da56fd3100e322193d196a5e287829a8d35e",
+		"28ce06bc03dd1f92d0c81786540c9a1cf68ddf14851a8356d52d5892cf91d4",
+		"851de3ecd392bce6397e90080047dc6bf0d0a3d6cb53a38496cc8fce879a7f",
+	},
+	{
+		"4450af7f2cea07b4959dfec9ed8c4bc9dc78587a27ed32167a572d7ae5f44351",
+		"34",
+		"69266ae780fa50250978603f68fd1bab2ca3bf6c5ee6d8a98497c523eec2fc24",
+		"8b7aeac468ba6002920c5b8fbc586f0132ae9e9f47fd02d0edaec550ff56ca46",
+	},
+	{
+		"1a6746881e6b62bb1fff761728f31511",
+		"9721b3179289d3e25df3d49f1f788f",
+		"32ea8ecb71be33af50eb5a3a6e3c7433f8eb15fd6d2306646d237af8b69274eb220c20cd67686f3e9068bd083caad688",
+		"7b9067d2d82f1ddf73223d1e45a88bc1d3404223503a490f49e8f8ee673ffe912171b3f71d6bd0eaedba2a868e34ce8e",
+	},
+	{
+		"5d34a823a3eea6d588730b5305d77321405be3da695b7ed0ffb11fc65918cc13",
+		"00ecd80b90f54665743aea3565012c56d25fbf17cc6bb30fbdd16203bacdaa2f",
+		"9178dc9406c36181d19e3f9bd7909fafafa013a7c1ce2e2cc677b21260bc4f10259cd9e2e18780c2991492d6ab8e611315f56ac846853699e769f1dd0790228a01e9f5d230f77025f19c543b03a587270f4ffac9ed84d00fb95f5de434af97a95844fde3",
+		"f5440dfa50ad4e2a3b7bab2bf6aba948f509a15ad42ce3466c5de481ff87e384acabc8368a767ffa3f10e9bfd19311e072050e2f2fcb0d94136bef40380ce2f94d5254f7f28a7fd183d7f527046cfb2e3742dd59052b4a5b2947f99840f0732a08285d63",
+	},
+	{
+		"62672e199918ee7278cb7927c290635994670992aa9bf7626547536be02958e7",
+		"84d4de4fb97207604fdb9db6d98eb941b0",
+		"64260a7f79b94e4f8126b5c51113d710ea20c1f5992b96d65695aebdc00b62c36f0eedebc5beef83217e04ddba421c415e752cb5981938b21423335721e01379caa16fdc2aa35c5705f8641de4bcceb0f758361dda6989502ea828c23405aa02804fe3fd6ed474015c564f1c661d9fd4770fa503890c43905f36ee935fa5c0899007ec451e369f50fbae07c06e6729adf79792c280db9fc3fee377f0aaa3d44db20b4ac2496986a2fce325b15058d6b82f7fb944bb3584752faf1d2dc810d6d48142e3d02c6a960bc6b027827daa747ef1af7e7f4b5ab0dd3bc6b3d8bebec931c215946ad02835e9eb68b857bbab6d2ae3cf30d625db18e87095af5b20675d",
+		"09fd53b242735bc1643e53e37ba8f1397d182f6b383c104be184a2309cae87ac213679a5e9f4fede793acb0c7d85d3f82393e41a02fc6e1259ea67cd1159a1cfa56c86b6ebb6376f29774660cca70b49343e74fd4fba199c65426201f29eabfc4e08edf1056275502136e32835421ebd158b7d0f1abdce92ae0a8884379ed1778dae6320c9a735795d114efd37d6a471a4222e775fb279315fa7eb6f44b3704e3c7e32bedd4dc02d1a3ad57924978170e6e40452d6c3555605f689e0020d3cb966214b2dae5b405335eb745b3d0c7d732673de2b3cb4267cafe3a77d3f25054557befd7f5ac36bf744735bd7f2c4df3b7d3eef761b4b9ae03b464a888b2a7c",
+	},
+}
+
+func TestHCTR2(t *testing.T) {
+	for _, impl := range impls {
+		t.Logf("Testing implementation: %v\n", impl.name)
+		for i, vec := range hctr2Vectors {
+			key, err := hex.DecodeString(vec.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tweak, err := hex.DecodeString(vec.tweak)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pt, err := hex.DecodeString(vec.plaintext)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ct, err := hex.DecodeString(vec.ciphertext)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			h, err := NewHCTR2(impl.ctor(key))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			dst := make([]byte, len(pt))
+			h.Encrypt(dst, pt, tweak)
+			assertEqual(t, i, ct, dst)
+
+			h.Decrypt(dst, ct, tweak)
+			assertEqual(t, i, pt, dst)
+
+			// In-place.
+			copy(dst, pt)
+			h.Encrypt(dst, dst, tweak)
+			assertEqual(t, i, ct, dst)
+			h.Decrypt(dst, dst, tweak)
+			assertEqual(t, i, pt, dst)
+
+			// Changing the tweak changes the ciphertext.
+			h.Encrypt(dst, pt, append(tweak, 0))
+			if bytes.Equal(dst[:BlockSize], ct[:BlockSize]) {
+				t.Fatalf("[%d] Encrypt ignored the tweak", i)
+			}
+		}
+	}
+}
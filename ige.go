@@ -0,0 +1,44 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package bsaes
+
+import (
+	"crypto/cipher"
+
+	"git.schwanenlied.me/yawning/bsaes.git/internal/modes"
+)
+
+// NewIGEEncrypter returns a cipher.BlockMode which encrypts in IGE (Infinite
+// Garble Extension) mode, using the given cipher.Block.  The iv must be twice
+// the Block's block size, and is the initial ciphertext block followed by the
+// initial plaintext block, as in OpenSSL.
+func NewIGEEncrypter(b cipher.Block, iv []byte) cipher.BlockMode {
+	return modes.NewIGE(b, iv, false)
+}
+
+// NewIGEDecrypter returns a cipher.BlockMode which decrypts in IGE mode,
+// using the given cipher.Block.  The iv must be twice the Block's block size,
+// and must match the iv used to encrypt the data.
+func NewIGEDecrypter(b cipher.Block, iv []byte) cipher.BlockMode {
+	return modes.NewIGE(b, iv, true)
+}
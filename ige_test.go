@@ -0,0 +1,88 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package bsaes
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// The test vectors are taken from OpenSSL's test/igetest.c.
+
+var igeVectors = []struct {
+	key        string
+	iv         string
+	plaintext  string
+	ciphertext string
+}{
+	{
+		"000102030405060708090a0b0c0d0e0f",
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"1a8519a6557be652e9da8e43da4ef4453cf456b4ca488aa383c79c98b34797cb",
+	},
+	{
+		"5468697320697320616e20696d706c65",
+		"6d656e746174696f6e206f6620494745206d6f646520666f72204f70656e5353",
+		"99706487a1cde613bc6de0b6f24b1c7aa448c8b9c3403e3467a8cad89340f53b",
+		"4c2e204c6574277320686f70652042656e20676f74206974207269676874210a",
+	},
+}
+
+func TestIGE(t *testing.T) {
+	for _, impl := range impls {
+		t.Logf("Testing implementation: %v\n", impl.name)
+		for i, vec := range igeVectors {
+			key, err := hex.DecodeString(vec.key[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			iv, err := hex.DecodeString(vec.iv[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			pt, err := hex.DecodeString(vec.plaintext[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			ct, err := hex.DecodeString(vec.ciphertext[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			b := impl.ctor(key)
+			dst := make([]byte, len(pt))
+			NewIGEEncrypter(b, iv).CryptBlocks(dst, pt)
+			assertEqual(t, i, ct, dst)
+
+			NewIGEDecrypter(b, iv).CryptBlocks(dst, dst)
+			assertEqual(t, i, pt, dst)
+
+			// Chaining across calls must match a single call.
+			dec := NewIGEDecrypter(b, iv)
+			dec.CryptBlocks(dst[:16], ct[:16])
+			dec.CryptBlocks(dst[16:], ct[16:])
+			assertEqual(t, i, pt, dst)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package aegis implements the AEGIS-128L and AEGIS-256 AEAD constructions,
+// on top of the bitsliced AES round function.
+package aegis
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"runtime"
+
+	"git.schwanenlied.me/yawning/bsaes.git/ct64"
+)
+
+const (
+	blockSize = 16
+
+	// Each of the 4 lanes of a bitsliced ct64 state holds one AES block,
+	// with the bits of lane i being those where bitIndex % 4 == i.
+	lane0 = 0x1111111111111111
+	lane1 = lane0 << 1
+)
+
+var (
+	c0 = [blockSize]byte{0x00, 0x01, 0x01, 0x02, 0x03, 0x05, 0x08, 0x0d, 0x15, 0x22, 0x37, 0x59, 0x90, 0xe9, 0x79, 0x62}
+	c1 = [blockSize]byte{0xdb, 0x3d, 0x18, 0x55, 0x6d, 0xc2, 0x2f, 0xf1, 0x20, 0x11, 0x31, 0x42, 0x73, 0xb5, 0x28, 0xdd}
+
+	zeroBlock [blockSize]byte
+
+	errFail = errors.New("cipher: message authentication failed")
+)
+
+type state interface {
+	init(key, nonce []byte)
+	absorb(blk []byte)
+	keystream(z []byte)
+	finalize(tag []byte, adLen, msgLen uint64)
+	reset()
+}
+
+// New128L returns an AEGIS-128L cipher.AEAD instance, with the 16 byte key,
+// and tag size of 16 or 32 bytes.
+func New128L(key []byte, tagSize int) (cipher.AEAD, error) {
+	if len(key) != 16 {
+		return nil, errors.New("bsaes/aegis: invalid AEGIS-128L key size")
+	}
+	return newAEADImpl(key, tagSize, 2*blockSize, func() state { return new(state128L) })
+}
+
+// New256 returns an AEGIS-256 cipher.AEAD instance, with the 32 byte key,
+// and tag size of 16 or 32 bytes.
+func New256(key []byte, tagSize int) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, errors.New("bsaes/aegis: invalid AEGIS-256 key size")
+	}
+	return newAEADImpl(key, tagSize, blockSize, func() state { return new(state256) })
+}
+
+type aeadImpl struct {
+	key      [32]byte
+	keySize  int
+	tagSize  int
+	rate     int
+	newState func() state
+}
+
+func (a *aeadImpl) NonceSize() int {
+	return a.keySize
+}
+
+func (a *aeadImpl) Overhead() int {
+	return a.tagSize
+}
+
+func (a *aeadImpl) Reset() {
+	memwipe(a.key[:])
+}
+
+func (a *aeadImpl) absorbAD(s state, additionalData []byte) {
+	for len(additionalData) >= a.rate {
+		s.absorb(additionalData[:a.rate])
+		additionalData = additionalData[a.rate:]
+	}
+	if len(additionalData) > 0 {
+		var blk [2 * blockSize]byte
+		copy(blk[:], additionalData)
+		s.absorb(blk[:a.rate])
+	}
+}
+
+func (a *aeadImpl) crypt(s state, dst, src []byte, decrypt bool) {
+	var z, blk [2 * blockSize]byte
+
+	for len(src) >= a.rate {
+		// Enc(xi): ci = xi ^ z, Update(xi)
+		// Dec(ci): xi = ci ^ z, Update(xi)
+		s.keystream(z[:a.rate])
+		xorBytes(dst[:a.rate], src[:a.rate], z[:a.rate])
+		if decrypt {
+			s.absorb(dst[:a.rate])
+		} else {
+			s.absorb(src[:a.rate])
+		}
+		dst, src = dst[a.rate:], src[a.rate:]
+	}
+	if r := len(src); r > 0 {
+		// The final partial block is zero padded, and for decryption, the
+		// padding is stripped from the plaintext before it is absorbed.
+		s.keystream(z[:a.rate])
+		copy(blk[:], src)
+		xorBytes(dst, src, z[:])
+		if decrypt {
+			copy(blk[:], dst[:r])
+		}
+		s.absorb(blk[:a.rate])
+	}
+
+	memwipe(z[:])
+	memwipe(blk[:])
+}
+
+func (a *aeadImpl) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != a.keySize {
+		panic("bsaes/aegis.Seal: nonce with invalid size provided")
+	}
+
+	sz := len(plaintext)
+	out := make([]byte, sz+a.tagSize)
+
+	s := a.newState()
+	s.init(a.key[:a.keySize], nonce)
+	a.absorbAD(s, additionalData)
+	a.crypt(s, out, plaintext, false)
+	s.finalize(out[sz:], uint64(len(additionalData)), uint64(sz))
+	s.reset()
+
+	dst = append(dst, out...)
+	return dst
+}
+
+func (a *aeadImpl) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != a.keySize {
+		panic("bsaes/aegis.Open: nonce with invalid size provided")
+	}
+
+	sz := len(ciphertext)
+	if sz < a.tagSize {
+		return nil, errFail
+	}
+	sz -= a.tagSize
+
+	out := make([]byte, sz)
+
+	var tag [2 * blockSize]byte
+	s := a.newState()
+	s.init(a.key[:a.keySize], nonce)
+	a.absorbAD(s, additionalData)
+	a.crypt(s, out, ciphertext[:sz], true)
+	s.finalize(tag[:a.tagSize], uint64(len(additionalData)), uint64(sz))
+	s.reset()
+
+	if subtle.ConstantTimeCompare(tag[:a.tagSize], ciphertext[sz:]) != 1 {
+		memwipe(out)
+		return nil, errFail
+	}
+
+	dst = append(dst, out...)
+	return dst, nil
+}
+
+func newAEADImpl(key []byte, tagSize, rate int, newState func() state) (cipher.AEAD, error) {
+	switch tagSize {
+	case 16, 32:
+	default:
+		return nil, errors.New("bsaes/aegis: invalid tag size")
+	}
+
+	a := new(aeadImpl)
+	a.keySize = copy(a.key[:], key)
+	a.tagSize = tagSize
+	a.rate = rate
+	a.newState = newState
+
+	runtime.SetFinalizer(a, (*aeadImpl).Reset)
+
+	return a, nil
+}
+
+// aesRound applies SubBytes, ShiftRows and MixColumns to each lane of q, the
+// AddRoundKey step being left to the caller.
+func aesRound(q *[8]uint64) {
+	ct64.Sbox(q)
+	ct64.ShiftRows(q)
+	ct64.MixColumns(q)
+}
+
+func loadLenBlock(q *[8]uint64, adLen, msgLen uint64) {
+	var l [blockSize]byte
+	binary.LittleEndian.PutUint64(l[0:], adLen<<3)
+	binary.LittleEndian.PutUint64(l[8:], msgLen<<3)
+	ct64.Load4xU32(q, l[:])
+}
+
+func memwipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+func memwipeU64(s []uint64) {
+	for i := range s {
+		s[i] = 0
+	}
+}
+
+func xorBytes(dst, a, b []byte) {
+	for i, v := range a {
+		dst[i] = v ^ b[i]
+	}
+}
@@ -0,0 +1,260 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package aegis
+
+import "git.schwanenlied.me/yawning/bsaes.git/ct64"
+
+// state128L is the AEGIS-128L state, with S0..S3 in the lanes of lo, and
+// S4..S7 in the lanes of hi.
+type state128L struct {
+	lo, hi [8]uint64
+}
+
+func (s *state128L) init(key, nonce []byte) {
+	var kn, kc0, kc1 [blockSize]byte
+	var m [8]uint64
+
+	// S0 = key ^ nonce, S1 = C1, S2 = C0, S3 = C1
+	// S4 = key ^ nonce, S5 = key ^ C0, S6 = key ^ C1, S7 = key ^ C0
+	xorBytes(kn[:], key, nonce)
+	xorBytes(kc0[:], key, c0[:])
+	xorBytes(kc1[:], key, c1[:])
+	ct64.Load16xU32(&s.lo, kn[:], c1[:], c0[:], c1[:])
+	ct64.Load16xU32(&s.hi, kn[:], kc0[:], kc1[:], kc0[:])
+
+	// Repeat(10, Update(nonce, key))
+	ct64.Load16xU32(&m, nonce, key, zeroBlock[:], zeroBlock[:])
+	for i := 0; i < 10; i++ {
+		s.update(&m)
+	}
+
+	memwipe(kn[:])
+	memwipe(kc0[:])
+	memwipe(kc1[:])
+	memwipeU64(m[:])
+}
+
+// update is Update(M0, M1), with M0 and M1 in lanes 0 and 1 of m.
+func (s *state128L) update(m *[8]uint64) {
+	// S'i = AESRound(S{i-1}, Si), with M0 and M1 added to S0 and S4.
+	//
+	// All 8 rounds are done in 2 bitsliced calls, and the round outputs are
+	// rotated by one lane (across lo and hi) to line them up with the keys.
+	t0, t1 := s.lo, s.hi
+	aesRound(&t0)
+	aesRound(&t1)
+	for i := range s.lo {
+		r0 := ((t0[i] << 1) &^ lane0) | ((t1[i] >> 3) & lane0)
+		r1 := ((t1[i] << 1) &^ lane0) | ((t0[i] >> 3) & lane0)
+		s.lo[i] ^= r0 ^ (m[i] & lane0)
+		s.hi[i] ^= r1 ^ ((m[i] >> 1) & lane0)
+	}
+}
+
+func (s *state128L) absorb(blk []byte) {
+	var m [8]uint64
+	ct64.Load16xU32(&m, blk[0:], blk[blockSize:], zeroBlock[:], zeroBlock[:])
+	s.update(&m)
+	memwipeU64(m[:])
+}
+
+func (s *state128L) keystream(z []byte) {
+	var q [8]uint64
+	var scratch [blockSize]byte
+
+	// z0 = S6 ^ S1 ^ (S2 & S3)
+	// z1 = S2 ^ S5 ^ (S6 & S7)
+	for i := range q {
+		lo, hi := s.lo[i], s.hi[i]
+		z0 := (hi >> 2) ^ (lo >> 1) ^ ((lo >> 2) & (lo >> 3))
+		z1 := (lo >> 2) ^ (hi >> 1) ^ ((hi >> 2) & (hi >> 3))
+		q[i] = (z0 & lane0) | ((z1 & lane0) << 1)
+	}
+	ct64.Store16xU32(z[0:], z[blockSize:], scratch[:], scratch[:], &q)
+
+	memwipeU64(q[:])
+	memwipe(scratch[:])
+}
+
+func (s *state128L) finalize(tag []byte, adLen, msgLen uint64) {
+	var q [8]uint64
+	var scratch [blockSize]byte
+
+	// t = S2 ^ (LE64(ad_len_bits) || LE64(msg_len_bits))
+	// Repeat(7, Update(t, t))
+	loadLenBlock(&q, adLen, msgLen)
+	for i := range q {
+		t := ((s.lo[i] >> 2) & lane0) ^ q[i]
+		q[i] = t | (t << 1)
+	}
+	for i := 0; i < 7; i++ {
+		s.update(&q)
+	}
+
+	// tag = S0 ^ S1 ^ S2 ^ S3 ^ S4 ^ S5 ^ S6
+	// tag = (S0 ^ S1 ^ S2 ^ S3) || (S4 ^ S5 ^ S6 ^ S7)
+	for i := range q {
+		lo, hi := s.lo[i], s.hi[i]
+		tLo := lo ^ (lo >> 1) ^ (lo >> 2) ^ (lo >> 3)
+		tHi := hi ^ (hi >> 1) ^ (hi >> 2) ^ (hi >> 3)
+		if len(tag) == blockSize {
+			q[i] = (tLo ^ tHi ^ (hi >> 3)) & lane0
+		} else {
+			q[i] = (tLo & lane0) | ((tHi & lane0) << 1)
+		}
+	}
+	if len(tag) == blockSize {
+		ct64.Store4xU32(tag, &q)
+	} else {
+		ct64.Store16xU32(tag[0:], tag[blockSize:], scratch[:], scratch[:], &q)
+	}
+
+	memwipeU64(q[:])
+	memwipe(scratch[:])
+}
+
+func (s *state128L) reset() {
+	memwipeU64(s.lo[:])
+	memwipeU64(s.hi[:])
+}
+
+// state256 is the AEGIS-256 state, with S0..S3 in the lanes of lo, and S4
+// and S5 in lanes 0 and 1 of hi.  Lanes 2 and 3 of hi are always 0.
+type state256 struct {
+	lo, hi [8]uint64
+}
+
+func (s *state256) init(key, nonce []byte) {
+	var kn0, kn1, kc0, kc1 [blockSize]byte
+	var q, m [8]uint64
+
+	k0, k1 := key[:blockSize], key[blockSize:]
+	n0, n1 := nonce[:blockSize], nonce[blockSize:]
+
+	// S0 = k0 ^ n0, S1 = k1 ^ n1, S2 = C1, S3 = C0
+	// S4 = k0 ^ C0, S5 = k1 ^ C1
+	xorBytes(kn0[:], k0, n0)
+	xorBytes(kn1[:], k1, n1)
+	xorBytes(kc0[:], k0, c0[:])
+	xorBytes(kc1[:], k1, c1[:])
+	ct64.Load16xU32(&s.lo, kn0[:], kn1[:], c1[:], c0[:])
+	ct64.Load16xU32(&s.hi, kc0[:], kc1[:], zeroBlock[:], zeroBlock[:])
+
+	// Repeat(4,
+	//   Update(k0)
+	//   Update(k1)
+	//   Update(k0 ^ n0)
+	//   Update(k1 ^ n1)
+	// )
+	ct64.Load16xU32(&q, k0, k1, kn0[:], kn1[:])
+	for i := 0; i < 4; i++ {
+		for j := uint(0); j < 4; j++ {
+			for k := range m {
+				m[k] = (q[k] >> j) & lane0
+			}
+			s.update(&m)
+		}
+	}
+
+	memwipe(kn0[:])
+	memwipe(kn1[:])
+	memwipe(kc0[:])
+	memwipe(kc1[:])
+	memwipeU64(q[:])
+	memwipeU64(m[:])
+}
+
+// update is Update(M), with M in lane 0 of m.
+func (s *state256) update(m *[8]uint64) {
+	// S'i = AESRound(S{i-1}, Si), with M added to S0.
+	t0, t1 := s.lo, s.hi
+	aesRound(&t0)
+	aesRound(&t1)
+	for i := range s.lo {
+		r0 := ((t0[i] << 1) &^ lane0) | ((t1[i] >> 1) & lane0)
+		r1 := ((t0[i] >> 3) & lane0) | ((t1[i] << 1) & lane1)
+		s.lo[i] ^= r0 ^ (m[i] & lane0)
+		s.hi[i] ^= r1
+	}
+}
+
+func (s *state256) absorb(blk []byte) {
+	var m [8]uint64
+	ct64.Load4xU32(&m, blk)
+	s.update(&m)
+	memwipeU64(m[:])
+}
+
+func (s *state256) keystream(z []byte) {
+	var q [8]uint64
+
+	// z = S1 ^ S4 ^ S5 ^ (S2 & S3)
+	for i := range q {
+		lo, hi := s.lo[i], s.hi[i]
+		q[i] = ((lo >> 1) ^ hi ^ (hi >> 1) ^ ((lo >> 2) & (lo >> 3))) & lane0
+	}
+	ct64.Store4xU32(z, &q)
+
+	memwipeU64(q[:])
+}
+
+func (s *state256) finalize(tag []byte, adLen, msgLen uint64) {
+	var q [8]uint64
+	var scratch [blockSize]byte
+
+	// t = S3 ^ (LE64(ad_len_bits) || LE64(msg_len_bits))
+	// Repeat(7, Update(t))
+	loadLenBlock(&q, adLen, msgLen)
+	for i := range q {
+		q[i] ^= (s.lo[i] >> 3) & lane0
+	}
+	for i := 0; i < 7; i++ {
+		s.update(&q)
+	}
+
+	// tag = S0 ^ S1 ^ S2 ^ S3 ^ S4 ^ S5
+	// tag = (S0 ^ S1 ^ S2) || (S3 ^ S4 ^ S5)
+	for i := range q {
+		lo, hi := s.lo[i], s.hi[i]
+		tLo := lo ^ (lo >> 1) ^ (lo >> 2)
+		tHi := (lo >> 3) ^ hi ^ (hi >> 1)
+		if len(tag) == blockSize {
+			q[i] = (tLo ^ tHi) & lane0
+		} else {
+			q[i] = (tLo & lane0) | ((tHi & lane0) << 1)
+		}
+	}
+	if len(tag) == blockSize {
+		ct64.Store4xU32(tag, &q)
+	} else {
+		ct64.Store16xU32(tag[0:], tag[blockSize:], scratch[:], scratch[:], &q)
+	}
+
+	memwipeU64(q[:])
+	memwipe(scratch[:])
+}
+
+func (s *state256) reset() {
+	memwipeU64(s.lo[:])
+	memwipeU64(s.hi[:])
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package deoxys
+
+const maxStride = 4
+
+// bcLanes is the backend independent per-block state of a bitsliced
+// Deoxys-BC call, with a tweak (TK1) and a block for each lane.
+type bcLanes struct {
+	tk   [maxStride][tweakSize]byte
+	blks [maxStride][blockSize]byte
+	n    int
+}
+
+func (l *bcLanes) load(src []byte, tweaks [][tweakSize]byte) {
+	l.n = len(tweaks)
+	for i := 0; i < l.n; i++ {
+		l.tk[i] = tweaks[i]
+		copy(l.blks[i][:], src[i*blockSize:])
+	}
+}
+
+// nextTweaks updates the tweaks for the next round, TK1_{i+1} = h(TK1_i).
+func (l *bcLanes) nextTweaks() {
+	for i := 0; i < l.n; i++ {
+		h(&l.tk[i])
+	}
+}
+
+func (l *bcLanes) store(dst []byte) {
+	for i := 0; i < l.n; i++ {
+		copy(dst[i*blockSize:], l.blks[i][:])
+	}
+}
+
+func (l *bcLanes) wipe() {
+	for i := range l.tk {
+		memwipe(l.tk[i][:])
+		memwipe(l.blks[i][:])
+	}
+}
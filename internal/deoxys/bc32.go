@@ -0,0 +1,87 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package deoxys
+
+import "git.schwanenlied.me/yawning/bsaes.git/ct32"
+
+type bc32 struct {
+	derivedKQs [maxRounds + 1][8]uint32
+	rounds     int
+}
+
+func (b *bc32) stride() int {
+	return 2
+}
+
+func (b *bc32) encrypt(dst, src []byte, tweaks [][tweakSize]byte) {
+	var q, stk [8]uint32
+	var l bcLanes
+	l.load(src, tweaks)
+
+	// STK_i = TK1_i ^ (TK2_i ^ TK3_i ^ RC_i), TK1_{i+1} = h(TK1_i)
+	loadSTK := func(i int) {
+		if i > 0 {
+			l.nextTweaks()
+		}
+		ct32.Load8xU32(&stk, l.tk[0][:], l.tk[1][:])
+		ct32.AddRoundKey(&stk, b.derivedKQs[i][:])
+	}
+
+	ct32.Load8xU32(&q, l.blks[0][:], l.blks[1][:])
+	loadSTK(0)
+	ct32.AddRoundKey(&q, stk[:])
+	for i := 1; i <= b.rounds; i++ {
+		ct32.Sbox(&q)
+		ct32.ShiftRows(&q)
+		ct32.MixColumns(&q)
+		loadSTK(i)
+		ct32.AddRoundKey(&q, stk[:])
+	}
+	ct32.Store8xU32(l.blks[0][:], l.blks[1][:], &q)
+	l.store(dst)
+
+	memwipeU32(q[:])
+	memwipeU32(stk[:])
+	l.wipe()
+}
+
+func (b *bc32) reset() {
+	for i := range b.derivedKQs {
+		memwipeU32(b.derivedKQs[i][:])
+	}
+}
+
+func newBC32(derivedKs *[maxRounds + 1][blockSize]byte, rounds int) bcImpl {
+	b := new(bc32)
+	b.rounds = rounds
+	for i := 0; i <= rounds; i++ {
+		ct32.RkeyOrtho(b.derivedKQs[i][:], derivedKs[i][:])
+	}
+	return b
+}
+
+func memwipeU32(s []uint32) {
+	for i := range s {
+		s[i] = 0
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package deoxys
+
+import "git.schwanenlied.me/yawning/bsaes.git/ct64"
+
+type bc64 struct {
+	derivedKQs [maxRounds + 1][8]uint64
+	rounds     int
+}
+
+func (b *bc64) stride() int {
+	return 4
+}
+
+func (b *bc64) encrypt(dst, src []byte, tweaks [][tweakSize]byte) {
+	var q, stk [8]uint64
+	var l bcLanes
+	l.load(src, tweaks)
+
+	// STK_i = TK1_i ^ (TK2_i ^ TK3_i ^ RC_i), TK1_{i+1} = h(TK1_i)
+	loadSTK := func(i int) {
+		if i > 0 {
+			l.nextTweaks()
+		}
+		ct64.Load16xU32(&stk, l.tk[0][:], l.tk[1][:], l.tk[2][:], l.tk[3][:])
+		ct64.AddRoundKey(&stk, b.derivedKQs[i][:])
+	}
+
+	ct64.Load16xU32(&q, l.blks[0][:], l.blks[1][:], l.blks[2][:], l.blks[3][:])
+	loadSTK(0)
+	ct64.AddRoundKey(&q, stk[:])
+	for i := 1; i <= b.rounds; i++ {
+		ct64.Sbox(&q)
+		ct64.ShiftRows(&q)
+		ct64.MixColumns(&q)
+		loadSTK(i)
+		ct64.AddRoundKey(&q, stk[:])
+	}
+	ct64.Store16xU32(l.blks[0][:], l.blks[1][:], l.blks[2][:], l.blks[3][:], &q)
+	l.store(dst)
+
+	memwipeU64(q[:])
+	memwipeU64(stk[:])
+	l.wipe()
+}
+
+func (b *bc64) reset() {
+	for i := range b.derivedKQs {
+		memwipeU64(b.derivedKQs[i][:])
+	}
+}
+
+func newBC64(derivedKs *[maxRounds + 1][blockSize]byte, rounds int) bcImpl {
+	b := new(bc64)
+	b.rounds = rounds
+	for i := 0; i <= rounds; i++ {
+		ct64.RkeyOrtho(b.derivedKQs[i][:], derivedKs[i][:])
+	}
+	return b
+}
+
+func memwipeU64(s []uint64) {
+	for i := range s {
+		s[i] = 0
+	}
+}
@@ -0,0 +1,353 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package deoxys implements the Deoxys-BC-256 and Deoxys-BC-384 tweakable
+// block ciphers, and the Deoxys-II nonce misuse resistant AEAD mode, on top
+// of the bitsliced AES round function.
+package deoxys
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"runtime"
+)
+
+const (
+	blockSize = 16
+	tweakSize = 16
+
+	// NonceSize is the Deoxys-II nonce size in bytes.
+	NonceSize = 120 / 8
+
+	// TagSize is the Deoxys-II tag size in bytes.
+	TagSize = 16
+
+	maxRounds = 16
+
+	prefixADBlock  = 0x2 // 0010
+	prefixADFinal  = 0x6 // 0110
+	prefixMsgBlock = 0x0 // 0000
+	prefixMsgFinal = 0x4 // 0100
+	prefixTag      = 0x1 // 0001
+)
+
+var (
+	rcons = [maxRounds + 1]byte{
+		0x2f, 0x5e, 0xbc, 0x63, 0xc6, 0x97, 0x35, 0x6a,
+		0xd4, 0xb3, 0x7d, 0xfa, 0xef, 0xc5, 0x91, 0x39,
+		0x72,
+	}
+
+	errFail = errors.New("cipher: message authentication failed")
+)
+
+// bcImpl is a keyed Deoxys-BC instance.
+type bcImpl interface {
+	// stride returns the number of blocks that encrypt can process in
+	// parallel.
+	stride() int
+
+	// encrypt encrypts up to stride blocks from src to dst, with the
+	// corresponding tweak for each block.
+	encrypt(dst, src []byte, tweaks [][tweakSize]byte)
+
+	reset()
+}
+
+// NewCt64 returns a Deoxys-II cipher.AEAD instance with the 16 byte
+// (Deoxys-II-128-128) or 32 byte (Deoxys-II-256-128) key, backed by the ct64
+// round function.
+func NewCt64(key []byte) (cipher.AEAD, error) {
+	return newDeoxysII(key, newBC64)
+}
+
+// NewCt32 returns a Deoxys-II cipher.AEAD instance with the 16 byte
+// (Deoxys-II-128-128) or 32 byte (Deoxys-II-256-128) key, backed by the ct32
+// round function.
+func NewCt32(key []byte) (cipher.AEAD, error) {
+	return newDeoxysII(key, newBC32)
+}
+
+func newDeoxysII(key []byte, ctor func(*[maxRounds + 1][blockSize]byte, int) bcImpl) (cipher.AEAD, error) {
+	var derivedKs [maxRounds + 1][blockSize]byte
+
+	rounds, err := stkDeriveK(&derivedKs, key)
+	if err != nil {
+		return nil, err
+	}
+
+	d := new(deoxysIIImpl)
+	d.bc = ctor(&derivedKs, rounds)
+	for i := range derivedKs {
+		memwipe(derivedKs[i][:])
+	}
+
+	runtime.SetFinalizer(d, (*deoxysIIImpl).Reset)
+
+	return d, nil
+}
+
+// h is the tweakey byte permutation.
+func h(t *[tweakSize]byte) {
+	t[0], t[1], t[2], t[3], t[4], t[5], t[6], t[7], t[8], t[9], t[10], t[11], t[12], t[13], t[14], t[15] = t[1], t[6], t[11], t[12], t[5], t[10], t[15], t[0], t[9], t[14], t[3], t[4], t[13], t[2], t[7], t[8]
+}
+
+func lfsr2(t *[tweakSize]byte) {
+	// x7 || x6 || x5 || x4 || x3 || x2 || x1 || x0 ->
+	// x6 || x5 || x4 || x3 || x2 || x1 || x0 || x7 ^ x5
+	for i, x := range t {
+		t[i] = (x << 1) | ((x >> 7) ^ ((x >> 5) & 1))
+	}
+}
+
+func lfsr3(t *[tweakSize]byte) {
+	// x7 || x6 || x5 || x4 || x3 || x2 || x1 || x0 ->
+	// x0 ^ x6 || x7 || x6 || x5 || x4 || x3 || x2 || x1
+	for i, x := range t {
+		t[i] = (x >> 1) | (((x & 1) ^ ((x >> 6) & 1)) << 7)
+	}
+}
+
+// stkDeriveK derives the key (TK2, TK3) and round constant contribution to
+// each of the sub-tweakeys, and returns the number of rounds.  The tweak
+// (TK1) contribution varies per block, and is added by the bcImpl.
+func stkDeriveK(derivedKs *[maxRounds + 1][blockSize]byte, key []byte) (int, error) {
+	var tk2, tk3 [tweakSize]byte
+
+	// The tweakey is key || tweak, so the last 128 bits of the key are TK2,
+	// and the first 128 bits (Deoxys-BC-384 only) are TK3.
+	var rounds int
+	switch len(key) {
+	case 16:
+		rounds = 14
+		copy(tk2[:], key)
+	case 32:
+		rounds = 16
+		copy(tk3[:], key[0:16])
+		copy(tk2[:], key[16:32])
+	default:
+		return 0, errors.New("bsaes/deoxys: invalid key size")
+	}
+
+	for i := 0; i <= rounds; i++ {
+		if i > 0 {
+			// TK2_{i+1} = h(LFSR2(TK2_i)), TK3_{i+1} = h(LFSR3(TK3_i))
+			lfsr2(&tk2)
+			h(&tk2)
+			if len(key) == 32 {
+				lfsr3(&tk3)
+				h(&tk3)
+			}
+		}
+
+		// RC_i = 1 || 2 || 4 || 8 || rcon_i || rcon_i || rcon_i || rcon_i || 0...
+		dk := &derivedKs[i]
+		xorBytes(dk[:], tk2[:], tk3[:])
+		dk[0] ^= 1
+		dk[1] ^= 2
+		dk[2] ^= 4
+		dk[3] ^= 8
+		for j := 4; j < 8; j++ {
+			dk[j] ^= rcons[i]
+		}
+	}
+
+	memwipe(tk2[:])
+	memwipe(tk3[:])
+
+	return rounds, nil
+}
+
+type deoxysIIImpl struct {
+	bc bcImpl
+}
+
+func (d *deoxysIIImpl) NonceSize() int {
+	return NonceSize
+}
+
+func (d *deoxysIIImpl) Overhead() int {
+	return TagSize
+}
+
+func (d *deoxysIIImpl) Reset() {
+	d.bc.reset()
+}
+
+func encodeTagTweak(t *[tweakSize]byte, prefix byte, blockNr int) {
+	// prefix || blockNr, with the block number limited to 64 bits, which is
+	// more than any slice can hold.
+	*t = [tweakSize]byte{}
+	t[0] = prefix << 4
+	binary.BigEndian.PutUint64(t[8:], uint64(blockNr))
+}
+
+func encodeEncTweak(t *[tweakSize]byte, tag []byte, blockNr int) {
+	// 1 || tag[1..127] ^ blockNr
+	copy(t[:], tag)
+	t[0] |= 0x80
+	v := binary.BigEndian.Uint64(t[8:]) ^ uint64(blockNr)
+	binary.BigEndian.PutUint64(t[8:], v)
+}
+
+func (d *deoxysIIImpl) auth(auth *[blockSize]byte, data []byte, prefixBlock, prefixFinal byte) {
+	stride := d.bc.stride()
+	buf := make([]byte, stride*blockSize)
+	tweaks := make([][tweakSize]byte, stride)
+
+	// Auth ^= E_K^(prefixBlock, i)(X_i)
+	idx := 0
+	for len(data) >= blockSize {
+		n := len(data) / blockSize
+		if n > stride {
+			n = stride
+		}
+		sz := n * blockSize
+
+		for i := 0; i < n; i++ {
+			encodeTagTweak(&tweaks[i], prefixBlock, idx+i)
+		}
+		d.bc.encrypt(buf[:sz], data[:sz], tweaks[:n])
+		for i := 0; i < sz; i += blockSize {
+			xorBytes(auth[:], auth[:], buf[i:i+blockSize])
+		}
+
+		idx += n
+		data = data[sz:]
+	}
+
+	// Auth ^= E_K^(prefixFinal, i)(pad10*(X_*))
+	if r := len(data); r > 0 {
+		var tmp [blockSize]byte
+		copy(tmp[:], data)
+		tmp[r] = 0x80
+		encodeTagTweak(&tweaks[0], prefixFinal, idx)
+		d.bc.encrypt(tmp[:], tmp[:], tweaks[:1])
+		xorBytes(auth[:], auth[:], tmp[:])
+		memwipe(tmp[:])
+	}
+
+	memwipe(buf)
+}
+
+func (d *deoxysIIImpl) tag(tag *[blockSize]byte, nonce, plaintext, additionalData []byte) {
+	d.auth(tag, additionalData, prefixADBlock, prefixADFinal)
+	d.auth(tag, plaintext, prefixMsgBlock, prefixMsgFinal)
+
+	// tag = E_K^(0001 || 0000 || N)(Auth)
+	var t [1][tweakSize]byte
+	t[0][0] = prefixTag << 4
+	copy(t[0][1:], nonce)
+	d.bc.encrypt(tag[:], tag[:], t[:])
+}
+
+func (d *deoxysIIImpl) xorKeyStream(dst, src, nonce []byte, tag *[blockSize]byte) {
+	stride := d.bc.stride()
+	buf := make([]byte, stride*blockSize)
+	ctr := make([]byte, stride*blockSize)
+	tweaks := make([][tweakSize]byte, stride)
+
+	// C_j = M_j ^ E_K^(1 || tag ^ j)(0^8 || N)
+	for i := 0; i < stride; i++ {
+		copy(ctr[i*blockSize+1:(i+1)*blockSize], nonce)
+	}
+	idx := 0
+	for len(src) > 0 {
+		n := (len(src) + blockSize - 1) / blockSize
+		if n > stride {
+			n = stride
+		}
+		sz := n * blockSize
+
+		for i := 0; i < n; i++ {
+			encodeEncTweak(&tweaks[i], tag[:], idx+i)
+		}
+		d.bc.encrypt(buf[:sz], ctr[:sz], tweaks[:n])
+		if len(src) < sz {
+			sz = len(src)
+		}
+		xorBytes(dst[:sz], src[:sz], buf)
+
+		idx += n
+		dst, src = dst[sz:], src[sz:]
+	}
+
+	memwipe(buf)
+}
+
+func (d *deoxysIIImpl) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != NonceSize {
+		panic("bsaes/deoxysIIImpl.Seal: nonce with invalid size provided")
+	}
+
+	sz := len(plaintext)
+	out := make([]byte, sz+TagSize)
+
+	var tag [blockSize]byte
+	d.tag(&tag, nonce, plaintext, additionalData)
+	d.xorKeyStream(out, plaintext, nonce, &tag)
+	copy(out[sz:], tag[:])
+
+	dst = append(dst, out...)
+	return dst
+}
+
+func (d *deoxysIIImpl) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != NonceSize {
+		panic("bsaes/deoxysIIImpl.Open: nonce with invalid size provided")
+	}
+
+	sz := len(ciphertext)
+	if sz < TagSize {
+		return nil, errFail
+	}
+	sz -= TagSize
+
+	var tag, expectedTag [blockSize]byte
+	copy(tag[:], ciphertext[sz:])
+
+	out := make([]byte, sz)
+	d.xorKeyStream(out, ciphertext[:sz], nonce, &tag)
+	d.tag(&expectedTag, nonce, out, additionalData)
+
+	if subtle.ConstantTimeCompare(tag[:], expectedTag[:]) != 1 {
+		memwipe(out)
+		return nil, errFail
+	}
+
+	dst = append(dst, out...)
+	return dst, nil
+}
+
+func memwipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+func xorBytes(dst, a, b []byte) {
+	for i, v := range a {
+		dst[i] = v ^ b[i]
+	}
+}
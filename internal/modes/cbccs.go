@@ -0,0 +1,201 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package modes
+
+import "crypto/cipher"
+
+// The CBC-CS (ciphertext stealing) variants, as specified in the addendum to
+// NIST Special Pub. 800-38A.
+const (
+	CBCCS1 = 1 + iota
+	CBCCS2
+	CBCCS3
+)
+
+// NewCBCCSEncrypter returns a CBC-CS encrypter cipher.BlockMode of the
+// specified variant.  Each call to CryptBlocks encrypts a complete message
+// of at least one block, and subsequent calls continue the chain from the
+// final CBC ciphertext block.
+func NewCBCCSEncrypter(b cipher.Block, iv []byte, variant int) cipher.BlockMode {
+	if len(iv) != b.BlockSize() || b.BlockSize() != blockSize {
+		panic("bsaes/NewCBCCSEncrypter: iv size does not match block size")
+	}
+	checkCBCCSVariant(variant)
+
+	c := new(cbcCSEncImpl)
+	c.ecb = toBulkECB(b)
+	copy(c.iv[:], iv)
+	c.variant = variant
+
+	return c
+}
+
+// NewCBCCSDecrypter returns a CBC-CS decrypter cipher.BlockMode of the
+// specified variant, with the CBC decryption done Stride blocks at a time.
+// Each call to CryptBlocks decrypts a complete message of at least one
+// block.
+func NewCBCCSDecrypter(b cipher.Block, iv []byte, variant int) cipher.BlockMode {
+	if len(iv) != b.BlockSize() || b.BlockSize() != blockSize {
+		panic("bsaes/NewCBCCSDecrypter: iv size does not match block size")
+	}
+	checkCBCCSVariant(variant)
+
+	c := new(cbcCSDecImpl)
+	c.ecb = toBulkECB(b)
+	c.dec = newCBCDecImpl(c.ecb, iv).(*cbcDecImpl)
+	c.variant = variant
+
+	return c
+}
+
+func checkCBCCSVariant(variant int) {
+	switch variant {
+	case CBCCS1, CBCCS2, CBCCS3:
+	default:
+		panic("bsaes: invalid CBC-CS variant")
+	}
+}
+
+// cbcCSSwapped returns true iff the final two blocks are swapped for a given
+// variant and partial final block size d.
+func cbcCSSwapped(variant, d int) bool {
+	switch variant {
+	case CBCCS2:
+		return d != blockSize
+	case CBCCS3:
+		return true
+	}
+	return false
+}
+
+type cbcCSEncImpl struct {
+	ecb bulkECBAble
+	iv  [blockSize]byte
+
+	variant int
+}
+
+func (c *cbcCSEncImpl) BlockSize() int {
+	return blockSize
+}
+
+func (c *cbcCSEncImpl) CryptBlocks(dst, src []byte) {
+	sLen := len(src)
+	if sLen < blockSize {
+		panic("bsaes/cbcCSEncImpl.CryptBlocks: input not at least one block")
+	}
+	if len(dst) < sLen {
+		panic("bsaes/cbcCSEncImpl.CryptBlocks: output smaller than input")
+	}
+
+	// Split the input into n-1 full blocks, and a final (possibly partial)
+	// block of d bytes.
+	nFull := (sLen - 1) / blockSize
+	d := sLen - nFull*blockSize
+
+	// CBC encryption is inherently serial.
+	for i := 0; i < nFull; i++ {
+		blk := dst[i*blockSize : (i+1)*blockSize]
+		xorBytes(blk, src[i*blockSize:(i+1)*blockSize], c.iv[:])
+		c.ecb.Encrypt(blk, blk)
+		copy(c.iv[:], blk)
+	}
+
+	// C_n = CIPH_K((P_n* || 0^(b-d)) xor C_{n-1})
+	var cn [blockSize]byte
+	copy(cn[:], src[nFull*blockSize:])
+	xorBytes(cn[:], cn[:], c.iv[:])
+	c.ecb.Encrypt(cn[:], cn[:])
+
+	if nFull == 0 {
+		copy(dst, cn[:])
+		c.iv = cn
+		return
+	}
+
+	// C_{n-1}* = MSB_d(C_{n-1})
+	tail := dst[(nFull-1)*blockSize : sLen]
+	if cbcCSSwapped(c.variant, d) {
+		copy(tail[blockSize:], c.iv[:d])
+		copy(tail, cn[:])
+	} else {
+		copy(tail, c.iv[:d])
+		copy(tail[d:], cn[:])
+	}
+	c.iv = cn
+}
+
+type cbcCSDecImpl struct {
+	ecb bulkECBAble
+	dec *cbcDecImpl
+
+	variant int
+}
+
+func (c *cbcCSDecImpl) BlockSize() int {
+	return blockSize
+}
+
+func (c *cbcCSDecImpl) CryptBlocks(dst, src []byte) {
+	sLen := len(src)
+	if sLen < blockSize {
+		panic("bsaes/cbcCSDecImpl.CryptBlocks: input not at least one block")
+	}
+	if len(dst) < sLen {
+		panic("bsaes/cbcCSDecImpl.CryptBlocks: output smaller than input")
+	}
+
+	nFull := (sLen - 1) / blockSize
+	d := sLen - nFull*blockSize
+	if nFull == 0 {
+		c.dec.CryptBlocks(dst, src)
+		return
+	}
+
+	// Extract C_{n-1}* and C_n, before anything gets overwritten.
+	var cn, cn1 [blockSize]byte
+	tail := src[(nFull-1)*blockSize : sLen]
+	if cbcCSSwapped(c.variant, d) {
+		copy(cn[:], tail)
+		copy(cn1[:], tail[blockSize:])
+	} else {
+		copy(cn1[:], tail[:d])
+		copy(cn[:], tail[d:])
+	}
+
+	// Z = CIPH^-1_K(C_n), C_{n-1} = C_{n-1}* || LSB_{b-d}(Z),
+	// P_n* = MSB_d(Z) xor C_{n-1}*
+	var z [blockSize]byte
+	c.ecb.Decrypt(z[:], cn[:])
+	copy(cn1[d:], z[d:])
+	xorBytes(z[:d], z[:d], cn1[:d])
+
+	// The rest is just CBC decryption, which can be done in parallel.
+	pfxLen := (nFull - 1) * blockSize
+	c.dec.CryptBlocks(dst[:pfxLen], src[:pfxLen])
+	c.dec.CryptBlocks(dst[pfxLen:pfxLen+blockSize], cn1[:])
+	copy(dst[pfxLen+blockSize:sLen], z[:d])
+
+	copy(c.dec.iv, cn[:])
+	memwipe(z[:])
+}
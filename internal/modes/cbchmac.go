@@ -0,0 +1,163 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package modes
+
+import (
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"runtime"
+)
+
+const cbcHMACNonceSize = blockSize
+
+// NewCBCHMAC returns an AES-CBC-HMAC-SHA2 (RFC 7518, Section 5.2) cipher.AEAD
+// instance.  The first half of the key is used as the HMAC key, and the
+// second half is used as the AES key, instantiated with ctor.  Tags are
+// truncated to tagSize bytes.
+func NewCBCHMAC(ctor func([]byte) cipher.Block, key []byte, h func() hash.Hash, tagSize int) (cipher.AEAD, error) {
+	keySize := len(key) / 2
+	switch keySize {
+	case 16, 24, 32:
+	default:
+		return nil, errors.New("bsaes/NewCBCHMAC: invalid key size")
+	}
+	if len(key) != 2*keySize || tagSize < 1 || tagSize > h().Size() {
+		return nil, errors.New("bsaes/NewCBCHMAC: invalid key or tag size")
+	}
+
+	c := new(cbcHMACImpl)
+	c.macKey = append([]byte{}, key[:keySize]...)
+	c.b = ctor(key[keySize:])
+	c.ecb = toBulkECB(c.b)
+	c.h = h
+	c.tagSize = tagSize
+
+	runtime.SetFinalizer(c, (*cbcHMACImpl).Reset)
+
+	return c, nil
+}
+
+type cbcHMACImpl struct {
+	b      cipher.Block
+	ecb    bulkECBAble
+	macKey []byte
+	h      func() hash.Hash
+
+	tagSize int
+}
+
+func (c *cbcHMACImpl) NonceSize() int {
+	return cbcHMACNonceSize
+}
+
+func (c *cbcHMACImpl) Overhead() int {
+	return blockSize + c.tagSize
+}
+
+func (c *cbcHMACImpl) Reset() {
+	memwipe(c.macKey)
+}
+
+func (c *cbcHMACImpl) tag(out, nonce, ciphertext, additionalData []byte) {
+	// M = MAC(MAC_KEY, A || IV || E || AL), T = M[:T_LEN]
+	var al [8]byte
+	binary.BigEndian.PutUint64(al[:], uint64(len(additionalData))<<3)
+
+	m := hmac.New(c.h, c.macKey)
+	m.Write(additionalData)
+	m.Write(nonce)
+	m.Write(ciphertext)
+	m.Write(al[:])
+
+	sum := m.Sum(nil)
+	copy(out, sum[:c.tagSize])
+	memwipe(sum)
+}
+
+func (c *cbcHMACImpl) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != cbcHMACNonceSize {
+		panic("bsaes/cbcHMACImpl.Seal: nonce with invalid size provided")
+	}
+
+	// PKCS #7 pad the plaintext, and encrypt it with CBC.
+	sz := len(plaintext)
+	padLen := blockSize - sz%blockSize
+	ctLen := sz + padLen
+	out := make([]byte, ctLen+c.tagSize)
+	copy(out, plaintext)
+	for i := sz; i < ctLen; i++ {
+		out[i] = byte(padLen)
+	}
+	cipher.NewCBCEncrypter(c.b, nonce).CryptBlocks(out[:ctLen], out[:ctLen])
+
+	c.tag(out[ctLen:], nonce, out[:ctLen], additionalData)
+
+	dst = append(dst, out...)
+	return dst
+}
+
+func (c *cbcHMACImpl) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != cbcHMACNonceSize {
+		panic("bsaes/cbcHMACImpl.Open: nonce with invalid size provided")
+	}
+
+	sz := len(ciphertext)
+	if sz < c.Overhead() {
+		return nil, errFail
+	}
+	sz -= c.tagSize
+	if sz%blockSize != 0 {
+		return nil, errFail
+	}
+
+	// Verify the tag before doing anything else.
+	expectedTag := make([]byte, c.tagSize)
+	c.tag(expectedTag, nonce, ciphertext[:sz], additionalData)
+	if subtle.ConstantTimeCompare(expectedTag, ciphertext[sz:]) != 1 {
+		return nil, errFail
+	}
+
+	out := make([]byte, sz)
+	newCBCDecImpl(c.ecb, nonce).CryptBlocks(out, ciphertext[:sz])
+
+	// The padding is authenticated by this point, but check it without
+	// branching anyway.
+	padLen := out[sz-1]
+	ok := subtle.ConstantTimeLessOrEq(1, int(padLen)) & subtle.ConstantTimeLessOrEq(int(padLen), blockSize)
+	for i := 1; i <= blockSize; i++ {
+		isPad := subtle.ConstantTimeLessOrEq(i, int(padLen))
+		ok &= subtle.ConstantTimeByteEq(out[sz-i], padLen) | (isPad ^ 1)
+	}
+	if ok != 1 {
+		memwipe(out)
+		return nil, errFail
+	}
+
+	dst = append(dst, out[:sz-int(padLen)]...)
+	memwipe(out)
+	return dst, nil
+}
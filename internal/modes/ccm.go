@@ -0,0 +1,201 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package modes
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+)
+
+// NewCCM returns a CCM (SP 800-38C) cipher.AEAD instance, with the specified
+// nonce (7 to 13 bytes), and tag (4, 6, 8, 10, 12, 14, or 16 bytes) sizes.
+func NewCCM(b cipher.Block, nonceSize, tagSize int) (cipher.AEAD, error) {
+	if b.BlockSize() != blockSize {
+		return nil, errors.New("bsaes/NewCCM: CCM requires 128 bit block sizes")
+	}
+	if nonceSize < 7 || nonceSize > 13 {
+		return nil, errors.New("bsaes/NewCCM: invalid nonce size")
+	}
+	if tagSize < 4 || tagSize > 16 || tagSize&1 != 0 {
+		return nil, errors.New("bsaes/NewCCM: invalid tag size")
+	}
+
+	c := new(ccmImpl)
+	c.ecb = toBulkECB(b)
+	c.nonceSize = nonceSize
+	c.tagSize = tagSize
+	return c, nil
+}
+
+type ccmImpl struct {
+	ecb bulkECBAble
+
+	nonceSize int
+	tagSize   int
+}
+
+func (c *ccmImpl) NonceSize() int {
+	return c.nonceSize
+}
+
+func (c *ccmImpl) Overhead() int {
+	return c.tagSize
+}
+
+func (c *ccmImpl) maxLength() uint64 {
+	q := uint(blockSize - 1 - c.nonceSize)
+	if q >= 8 {
+		return 1<<64 - 1
+	}
+	return 1<<(8*q) - 1
+}
+
+func (c *ccmImpl) cbcMAC(mac *[blockSize]byte, nonce, plaintext, additionalData []byte) {
+	q := blockSize - 1 - c.nonceSize
+
+	// B_0 = Flags || N || Q
+	var flags byte
+	if len(additionalData) > 0 {
+		flags |= 1 << 6
+	}
+	flags |= byte((c.tagSize-2)/2) << 3
+	flags |= byte(q - 1)
+
+	var b0 [blockSize]byte
+	var qBuf [8]byte
+	b0[0] = flags
+	copy(b0[1:], nonce)
+	binary.BigEndian.PutUint64(qBuf[:], uint64(len(plaintext)))
+	copy(b0[1+c.nonceSize:], qBuf[8-q:])
+	c.ecb.Encrypt(mac[:], b0[:])
+
+	if aLen := uint64(len(additionalData)); aLen > 0 {
+		// The length of the associated data is prepended to it, with an
+		// encoding dependent on the length.
+		var aHdr [10]byte
+		var hdrLen int
+		switch {
+		case aLen < (1<<16)-(1<<8):
+			binary.BigEndian.PutUint16(aHdr[:], uint16(aLen))
+			hdrLen = 2
+		case aLen <= 1<<32-1:
+			aHdr[0], aHdr[1] = 0xff, 0xfe
+			binary.BigEndian.PutUint32(aHdr[2:], uint32(aLen))
+			hdrLen = 6
+		default:
+			aHdr[0], aHdr[1] = 0xff, 0xff
+			binary.BigEndian.PutUint64(aHdr[2:], aLen)
+			hdrLen = 10
+		}
+
+		var tmp [blockSize]byte
+		n := copy(tmp[:], aHdr[:hdrLen])
+		n += copy(tmp[n:], additionalData)
+		xorBytes(mac[:], mac[:], tmp[:])
+		c.ecb.Encrypt(mac[:], mac[:])
+		c.cbcMACBlocks(mac, additionalData[n-hdrLen:])
+	}
+
+	c.cbcMACBlocks(mac, plaintext)
+}
+
+func (c *ccmImpl) cbcMACBlocks(mac *[blockSize]byte, data []byte) {
+	for len(data) >= blockSize {
+		xorBytes(mac[:], mac[:], data[:blockSize])
+		c.ecb.Encrypt(mac[:], mac[:])
+		data = data[blockSize:]
+	}
+	if len(data) > 0 {
+		// Zero pad the final block.
+		xorBytes(mac[:], data, mac[:])
+		c.ecb.Encrypt(mac[:], mac[:])
+	}
+}
+
+func (c *ccmImpl) ctr(s0 *[blockSize]byte, dst, src, nonce []byte) {
+	// Ctr_i = Flags || N || [i]_8q
+	var ctr [blockSize]byte
+	ctr[0] = byte(blockSize - 1 - c.nonceSize - 1)
+	copy(ctr[1:], nonce)
+	c.ecb.Encrypt(s0[:], ctr[:])
+
+	// The length limit on the payload prevents the counter from ever
+	// overflowing into the nonce, so the regular CTR mode can be used for
+	// the rest of the keystream.
+	ctr[blockSize-1] = 1
+	stream := newCTRImpl(c.ecb, ctr[:])
+	stream.XORKeyStream(dst, src)
+	stream.(*ctrImpl).Reset()
+}
+
+func (c *ccmImpl) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != c.nonceSize {
+		panic("bsaes/ccmImpl.Seal: nonce with invalid size provided")
+	}
+	sz := len(plaintext)
+	if uint64(sz) > c.maxLength() {
+		panic("bsaes/ccmImpl.Seal: plaintext too large")
+	}
+	out := make([]byte, sz+c.tagSize)
+
+	var mac, s0 [blockSize]byte
+	c.cbcMAC(&mac, nonce, plaintext, additionalData)
+	c.ctr(&s0, out, plaintext, nonce)
+
+	// T = MSB_Tlen(Y_r), C = (P xor MSB_Plen(S)) || (T xor MSB_Tlen(S_0))
+	xorBytes(out[sz:], mac[:c.tagSize], s0[:])
+
+	dst = append(dst, out...)
+	return dst
+}
+
+func (c *ccmImpl) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != c.nonceSize {
+		panic("bsaes/ccmImpl.Open: nonce with invalid size provided")
+	}
+
+	sz := len(ciphertext)
+	if sz < c.tagSize {
+		return nil, errFail
+	}
+	sz -= c.tagSize
+	if uint64(sz) > c.maxLength() {
+		return nil, errFail
+	}
+	out := make([]byte, sz)
+
+	var mac, s0 [blockSize]byte
+	c.ctr(&s0, out, ciphertext[:sz], nonce)
+	c.cbcMAC(&mac, nonce, out, additionalData)
+	xorBytes(mac[:], mac[:c.tagSize], s0[:])
+
+	if subtle.ConstantTimeCompare(mac[:c.tagSize], ciphertext[sz:]) != 1 {
+		memwipe(out)
+		return nil, errFail
+	}
+
+	dst = append(dst, out...)
+	return dst, nil
+}
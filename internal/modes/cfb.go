@@ -0,0 +1,181 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package modes
+
+import (
+	"crypto/cipher"
+	"runtime"
+)
+
+// NewCFB returns a CFB-128 cipher.Stream, which encrypts or decrypts with
+// the given cipher.Block and iv.  Decryption is done Stride blocks at a time
+// where possible.
+func NewCFB(b cipher.Block, iv []byte, decrypt bool) cipher.Stream {
+	if len(iv) != b.BlockSize() || b.BlockSize() != blockSize {
+		panic("bsaes/NewCFB: iv size does not match block size")
+	}
+
+	c := new(cfbImpl)
+	c.ecb = toBulkECB(b)
+	c.stride = c.ecb.Stride()
+	c.decrypt = decrypt
+	copy(c.next[:], iv)
+	c.outUsed = blockSize
+	c.buf = make([]byte, c.stride*blockSize)
+
+	runtime.SetFinalizer(c, (*cfbImpl).Reset)
+
+	return c
+}
+
+type cfbImpl struct {
+	ecb  bulkECBAble
+	next [blockSize]byte
+	out  [blockSize]byte
+	buf  []byte
+
+	outUsed int
+	stride  int
+	decrypt bool
+}
+
+func (c *cfbImpl) Reset() {
+	memwipe(c.next[:])
+	memwipe(c.out[:])
+	memwipe(c.buf)
+}
+
+func (c *cfbImpl) XORKeyStream(dst, src []byte) {
+	if len(dst) < len(src) {
+		panic("bsaes/cfbImpl.XORKeyStream: output smaller than input")
+	}
+
+	bulkSz := c.stride * blockSize
+	for len(src) > 0 {
+		// On block boundaries, decryption can process Stride blocks at
+		// once, since the ciphertext is known in advance.
+		if c.decrypt && c.stride > 1 && c.outUsed == blockSize && len(src) >= bulkSz {
+			// I_1 = C_0 (or the IV), I_j = C_{j-1}
+			copy(c.buf, c.next[:])
+			copy(c.buf[blockSize:], src[:bulkSz-blockSize])
+			copy(c.next[:], src[bulkSz-blockSize:bulkSz])
+
+			c.ecb.BulkEncrypt(c.buf, c.buf)
+			xorBytes(dst[:bulkSz], src[:bulkSz], c.buf)
+
+			dst, src = dst[bulkSz:], src[bulkSz:]
+			continue
+		}
+
+		if c.outUsed == blockSize {
+			c.ecb.Encrypt(c.out[:], c.next[:])
+			c.outUsed = 0
+		}
+
+		n := blockSize - c.outUsed
+		if sLen := len(src); sLen < n {
+			n = sLen
+		}
+		if c.decrypt {
+			copy(c.next[c.outUsed:], src[:n])
+		}
+		xorBytes(dst[:n], src[:n], c.out[c.outUsed:])
+		if !c.decrypt {
+			copy(c.next[c.outUsed:], dst[:n])
+		}
+
+		dst, src = dst[n:], src[n:]
+		c.outUsed += n
+	}
+}
+
+// NewCFB8 returns a CFB-8 cipher.Stream, which encrypts or decrypts with the
+// given cipher.Block and iv.  Decryption is done Stride bytes at a time where
+// possible.
+func NewCFB8(b cipher.Block, iv []byte, decrypt bool) cipher.Stream {
+	if len(iv) != b.BlockSize() || b.BlockSize() != blockSize {
+		panic("bsaes/NewCFB8: iv size does not match block size")
+	}
+
+	c := new(cfb8Impl)
+	c.ecb = toBulkECB(b)
+	c.stride = c.ecb.Stride()
+	c.decrypt = decrypt
+	c.reg = make([]byte, blockSize+c.stride)
+	copy(c.reg, iv)
+	c.buf = make([]byte, c.stride*blockSize)
+
+	runtime.SetFinalizer(c, (*cfb8Impl).Reset)
+
+	return c
+}
+
+type cfb8Impl struct {
+	ecb bulkECBAble
+	reg []byte
+	buf []byte
+
+	stride  int
+	decrypt bool
+}
+
+func (c *cfb8Impl) Reset() {
+	memwipe(c.reg)
+	memwipe(c.buf)
+}
+
+func (c *cfb8Impl) XORKeyStream(dst, src []byte) {
+	if len(dst) < len(src) {
+		panic("bsaes/cfb8Impl.XORKeyStream: output smaller than input")
+	}
+
+	for len(src) > 0 {
+		// For decryption, the shift register contents for each of the
+		// next Stride bytes are known in advance.
+		n := 1
+		if c.decrypt && len(src) >= c.stride {
+			n = c.stride
+		}
+
+		// I_j = the 16 bytes of (register || C) starting at j
+		copy(c.reg[blockSize:], src[:n])
+		for j := 0; j < n; j++ {
+			copy(c.buf[j*blockSize:(j+1)*blockSize], c.reg[j:])
+		}
+		if n == c.stride {
+			c.ecb.BulkEncrypt(c.buf, c.buf)
+		} else {
+			c.ecb.Encrypt(c.buf, c.buf)
+		}
+
+		for j := 0; j < n; j++ {
+			dst[j] = src[j] ^ c.buf[j*blockSize]
+		}
+		if !c.decrypt {
+			c.reg[blockSize] = dst[0]
+		}
+		copy(c.reg, c.reg[n:n+blockSize])
+
+		dst, src = dst[n:], src[n:]
+	}
+}
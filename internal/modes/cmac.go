@@ -0,0 +1,161 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package modes
+
+import (
+	"crypto/cipher"
+	"errors"
+	"hash"
+	"runtime"
+)
+
+// NewCMAC returns a CMAC (SP 800-38B) hash.Hash instance.
+func NewCMAC(b cipher.Block) (hash.Hash, error) {
+	if b.BlockSize() != blockSize {
+		return nil, errors.New("bsaes/NewCMAC: CMAC requires 128 bit block sizes")
+	}
+
+	return newCMACImpl(toBulkECB(b)), nil
+}
+
+// NewCMACPRF128 returns an AES-CMAC-PRF-128 (RFC 4615) hash.Hash instance,
+// with the variable length key, using ctor to instantiate the AES-128
+// instances.
+func NewCMACPRF128(ctor func([]byte) cipher.Block, key []byte) hash.Hash {
+	var k [blockSize]byte
+
+	// If VK is 128 bits, K = VK, otherwise K = AES-CMAC(0^128, VK).
+	if len(key) == blockSize {
+		copy(k[:], key)
+	} else {
+		ecb := toBulkECB(ctor(k[:]))
+		c := newCMACImpl(ecb)
+		c.Write(key)
+		c.sum(&k)
+		c.wipe()
+		ecb.Reset()
+	}
+
+	c := newCMACImpl(toBulkECB(ctor(k[:])))
+	memwipe(k[:])
+
+	return c
+}
+
+// cmacImpl is a constant time CMAC (SP 800-38B, also known as OMAC1)
+// implementation.
+type cmacImpl struct {
+	ecb bulkECBAble
+
+	k1 [blockSize]byte
+	k2 [blockSize]byte
+
+	x   [blockSize]byte
+	buf [blockSize]byte
+	n   int
+}
+
+func (c *cmacImpl) Size() int {
+	return blockSize
+}
+
+func (c *cmacImpl) BlockSize() int {
+	return blockSize
+}
+
+func (c *cmacImpl) Reset() {
+	memwipe(c.x[:])
+	memwipe(c.buf[:])
+	c.n = 0
+}
+
+func (c *cmacImpl) Write(p []byte) (int, error) {
+	pLen := len(p)
+	for len(p) > 0 {
+		// The final block is treated differently, so a full buffer is only
+		// processed once it is known that more data follows.
+		if c.n == blockSize {
+			xorBytes(c.x[:], c.x[:], c.buf[:])
+			c.ecb.Encrypt(c.x[:], c.x[:])
+			c.n = 0
+		}
+		n := copy(c.buf[c.n:], p)
+		c.n += n
+		p = p[n:]
+	}
+	return pLen, nil
+}
+
+func (c *cmacImpl) Sum(b []byte) []byte {
+	var tmp [blockSize]byte
+	c.sum(&tmp)
+	b = append(b, tmp[:]...)
+	memwipe(tmp[:])
+	return b
+}
+
+func (c *cmacImpl) sum(out *[blockSize]byte) {
+	var last [blockSize]byte
+
+	// If the final block is complete, M_n = M_n* xor K1, otherwise
+	// M_n = (M_n* || 10^j) xor K2.
+	copy(last[:], c.buf[:c.n])
+	if c.n == blockSize {
+		xorBytes(last[:], last[:], c.k1[:])
+	} else {
+		last[c.n] = 0x80
+		xorBytes(last[:], last[:], c.k2[:])
+	}
+
+	xorBytes(out[:], c.x[:], last[:])
+	c.ecb.Encrypt(out[:], out[:])
+	memwipe(last[:])
+}
+
+func (c *cmacImpl) wipe() {
+	c.Reset()
+	memwipe(c.k1[:])
+	memwipe(c.k2[:])
+}
+
+func (c *cmacImpl) init(ecb bulkECBAble) {
+	c.ecb = ecb
+
+	// L = CIPH_K(0^b), K1 = double(L), K2 = double(K1)
+	var l [blockSize]byte
+	c.ecb.Encrypt(l[:], l[:])
+	gfDouble(&l)
+	c.k1 = l
+	gfDouble(&l)
+	c.k2 = l
+	memwipe(l[:])
+}
+
+func newCMACImpl(ecb bulkECBAble) *cmacImpl {
+	c := new(cmacImpl)
+	c.init(ecb)
+
+	runtime.SetFinalizer(c, (*cmacImpl).wipe)
+
+	return c
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package modes
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"errors"
+)
+
+// NewEAX returns an EAX cipher.AEAD instance, with the specified nonce and tag
+// sizes.
+func NewEAX(b cipher.Block, nonceSize, tagSize int) (cipher.AEAD, error) {
+	if b.BlockSize() != blockSize {
+		return nil, errors.New("bsaes/NewEAX: EAX requires 128 bit block sizes")
+	}
+	if nonceSize < 1 {
+		return nil, errors.New("bsaes/NewEAX: invalid nonce size")
+	}
+	if tagSize < 1 || tagSize > blockSize {
+		return nil, errors.New("bsaes/NewEAX: invalid tag size")
+	}
+
+	e := new(eaxImpl)
+	e.ecb = toBulkECB(b)
+	e.mac = newCMACImpl(e.ecb)
+	e.nonceSize = nonceSize
+	e.tagSize = tagSize
+	return e, nil
+}
+
+type eaxImpl struct {
+	ecb bulkECBAble
+	mac *cmacImpl
+
+	nonceSize int
+	tagSize   int
+}
+
+func (e *eaxImpl) NonceSize() int {
+	return e.nonceSize
+}
+
+func (e *eaxImpl) Overhead() int {
+	return e.tagSize
+}
+
+func (e *eaxImpl) omac(out *[blockSize]byte, t byte, data []byte) {
+	// OMAC^t_K(M) = OMAC_K([t]_n || M)
+	var tBlk [blockSize]byte
+	tBlk[blockSize-1] = t
+
+	m := *e.mac
+	m.Write(tBlk[:])
+	m.Write(data)
+	m.sum(out)
+	m.wipe()
+}
+
+func (e *eaxImpl) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != e.nonceSize {
+		panic("bsaes/eaxImpl.Seal: nonce with invalid size provided")
+	}
+
+	sz := len(plaintext)
+	out := make([]byte, sz+e.tagSize)
+
+	// N = OMAC^0_K(N), H = OMAC^1_K(H), C = CTR^N_K(M), C = OMAC^2_K(C)
+	var n, h, c [blockSize]byte
+	e.omac(&n, 0, nonce)
+	e.omac(&h, 1, additionalData)
+	ctr := newCTRImpl(e.ecb, n[:])
+	ctr.XORKeyStream(out, plaintext)
+	ctr.(*ctrImpl).Reset()
+	e.omac(&c, 2, out[:sz])
+
+	// Tag = N xor C xor H
+	xorBytes(c[:], c[:], n[:])
+	xorBytes(c[:], c[:], h[:])
+	copy(out[sz:], c[:e.tagSize])
+
+	dst = append(dst, out...)
+	return dst
+}
+
+func (e *eaxImpl) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != e.nonceSize {
+		panic("bsaes/eaxImpl.Open: nonce with invalid size provided")
+	}
+
+	sz := len(ciphertext)
+	if sz < e.tagSize {
+		return nil, errFail
+	}
+	sz -= e.tagSize
+
+	var n, h, c [blockSize]byte
+	e.omac(&n, 0, nonce)
+	e.omac(&h, 1, additionalData)
+	e.omac(&c, 2, ciphertext[:sz])
+	xorBytes(c[:], c[:], n[:])
+	xorBytes(c[:], c[:], h[:])
+
+	if subtle.ConstantTimeCompare(c[:e.tagSize], ciphertext[sz:]) != 1 {
+		return nil, errFail
+	}
+
+	out := make([]byte, sz)
+	ctr := newCTRImpl(e.ecb, n[:])
+	ctr.XORKeyStream(out, ciphertext[:sz])
+	ctr.(*ctrImpl).Reset()
+
+	dst = append(dst, out...)
+	return dst, nil
+}
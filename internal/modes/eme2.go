@@ -0,0 +1,217 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package modes
+
+import (
+	"crypto/cipher"
+	"errors"
+	"runtime"
+)
+
+// eme2RemixInterval is the number of blocks after which the EME2 mask is
+// re-derived with an additional block cipher call.
+const eme2RemixInterval = 128
+
+// EME2 is an EME2-AES instance, implemented from the IEEE 1619.2 description.
+type EME2 struct {
+	ecb    bulkECBAble
+	adKey  [blockSize]byte
+	ecbKey [blockSize]byte
+}
+
+// NewEME2 returns an EME2-AES instance.  The key is K_AD || K_ECB || K_AES,
+// where K_AD and K_ECB are 16 bytes each, and K_AES is the AES key which is
+// instantiated with ctor.
+func NewEME2(ctor func([]byte) cipher.Block, key []byte) (*EME2, error) {
+	switch len(key) - 2*blockSize {
+	case 16, 24, 32:
+	default:
+		return nil, errors.New("bsaes/NewEME2: invalid key size")
+	}
+
+	e := new(EME2)
+	copy(e.adKey[:], key[0:blockSize])
+	copy(e.ecbKey[:], key[blockSize:2*blockSize])
+	e.ecb = toBulkECB(ctor(key[2*blockSize:]))
+
+	runtime.SetFinalizer(e, (*EME2).Reset)
+
+	return e, nil
+}
+
+// Reset clears the EME2 instance such that key material no longer appears in
+// process memory.
+func (e *EME2) Reset() {
+	memwipe(e.adKey[:])
+	memwipe(e.ecbKey[:])
+	e.ecb.Reset()
+}
+
+// Encrypt encrypts src with the tweak, and places the resulting output in
+// dst.  src must be at least 16 bytes long.
+func (e *EME2) Encrypt(dst, src, tweak []byte) {
+	e.crypt(dst, src, tweak, false)
+}
+
+// Decrypt decrypts src with the tweak, and places the resulting output in
+// dst.  src must be at least 16 bytes long.
+func (e *EME2) Decrypt(dst, src, tweak []byte) {
+	e.crypt(dst, src, tweak, true)
+}
+
+func (e *EME2) crypt(dst, src, tweak []byte, decrypt bool) {
+	sLen := len(src)
+	if sLen < blockSize {
+		panic("bsaes/EME2: data unit too short")
+	}
+	if len(dst) < sLen {
+		panic("bsaes/EME2: output smaller than input")
+	}
+
+	// Decryption is the same as encryption with the direction of every
+	// data block cipher call reversed.  The tweak is always encrypted.
+	ecbFn, fn := ecbEncryptBlocks, e.ecb.Encrypt
+	if decrypt {
+		ecbFn, fn = ecbDecryptBlocks, e.ecb.Decrypt
+	}
+
+	n, r := sLen/blockSize, sLen%blockSize
+	full := n * blockSize
+
+	var tStar, mp, mc, mm, m, m1, ccc1, tmp [blockSize]byte
+	e.hashTweak(&tStar, tweak)
+
+	// PPP_j = E_K(P_j ^ L_j), L_1 = K_ECB, L_{j+1} = alpha * L_j
+	e.mask(dst[:full], src[:full])
+	ecbFn(e.ecb, dst[:full])
+
+	// MP = PPP_1 ^ ... ^ PPP_m ^ pad(P_{m+1}) ^ T*
+	mp = tStar
+	for i := 0; i < full; i += blockSize {
+		xorBytes(mp[:], mp[:], dst[i:i+blockSize])
+	}
+	if r != 0 {
+		// MM = E_K(MP), MC = E_K(MM), C_{m+1} = P_{m+1} ^ MM
+		eme2Pad(&tmp, src[full:sLen])
+		xorBytes(mp[:], mp[:], tmp[:])
+		fn(mm[:], mp[:])
+		fn(mc[:], mm[:])
+		xorBytes(dst[full:sLen], src[full:sLen], mm[:])
+	} else {
+		// MC = E_K(MP)
+		fn(mc[:], mp[:])
+	}
+
+	// M_1 = MP ^ MC
+	xorBytes(m1[:], mp[:], mc[:])
+	m = m1
+	xorBytes(ccc1[:], mc[:], tStar[:])
+	for j := 1; j < n; j++ {
+		blk := dst[j*blockSize : (j+1)*blockSize]
+		if j%eme2RemixInterval == 0 {
+			// MP' = PPP_j ^ M_1, MC' = E_K(MP'), M = MP' ^ MC',
+			// CCC_j = MC' ^ M_1
+			xorBytes(tmp[:], blk, m1[:])
+			fn(blk, tmp[:])
+			xorBytes(m[:], tmp[:], blk)
+			xorBytes(blk, blk, m1[:])
+		} else {
+			// M = alpha * M, CCC_j = PPP_j ^ M
+			mulAlpha(&m)
+			xorBytes(blk, blk, m[:])
+		}
+		xorBytes(ccc1[:], ccc1[:], blk)
+	}
+
+	// CCC_1 = MC ^ T* ^ CCC_2 ^ ... ^ CCC_m ^ pad(C_{m+1})
+	if r != 0 {
+		eme2Pad(&tmp, dst[full:sLen])
+		xorBytes(ccc1[:], ccc1[:], tmp[:])
+	}
+	copy(dst[:blockSize], ccc1[:])
+
+	// C_j = E_K(CCC_j) ^ L_j
+	ecbFn(e.ecb, dst[:full])
+	e.mask(dst[:full], dst[:full])
+
+	memwipe(mp[:])
+	memwipe(mc[:])
+	memwipe(mm[:])
+	memwipe(m[:])
+	memwipe(m1[:])
+	memwipe(ccc1[:])
+	memwipe(tmp[:])
+}
+
+// hashTweak derives T* from the tweak.
+func (e *EME2) hashTweak(tStar *[blockSize]byte, tweak []byte) {
+	if len(tweak) == 0 {
+		// T* = E_K(K_AD)
+		e.ecb.Encrypt(tStar[:], e.adKey[:])
+		return
+	}
+
+	// K_1 = alpha * K_AD, K_{i+1} = alpha * K_i, with one extra
+	// multiplication for a partial final block, which is padded.
+	//
+	// T* = (E_K(T_1 ^ K_1) ^ K_1) ^ ... ^ (E_K(T_n ^ K_n) ^ K_n)
+	var k, tmp [blockSize]byte
+	k = e.adKey
+	*tStar = [blockSize]byte{}
+	for len(tweak) > 0 {
+		mulAlpha(&k)
+		if len(tweak) < blockSize {
+			eme2Pad(&tmp, tweak)
+			mulAlpha(&k)
+			tweak = nil
+		} else {
+			copy(tmp[:], tweak)
+			tweak = tweak[blockSize:]
+		}
+		xorBytes(tmp[:], tmp[:], k[:])
+		e.ecb.Encrypt(tmp[:], tmp[:])
+		xorBytes(tmp[:], tmp[:], k[:])
+		xorBytes(tStar[:], tStar[:], tmp[:])
+	}
+
+	memwipe(k[:])
+	memwipe(tmp[:])
+}
+
+// mask XORs each block of src with the ECB layer mask L_j, and places the
+// resulting output in dst.
+func (e *EME2) mask(dst, src []byte) {
+	l := e.ecbKey
+	for i := 0; i < len(src); i += blockSize {
+		xorBytes(dst[i:i+blockSize], src[i:i+blockSize], l[:])
+		mulAlpha(&l)
+	}
+	memwipe(l[:])
+}
+
+// eme2Pad sets dst to the partial block src, padded with 10*.
+func eme2Pad(dst *[blockSize]byte, src []byte) {
+	*dst = [blockSize]byte{}
+	copy(dst[:], src)
+	dst[len(src)] = 0x80
+}
@@ -107,6 +107,22 @@ func (g *gcmImpl) gctr(iv *[blockSize]byte, dst, src []byte) {
 	}
 }
 
+// tag computes the full size tag T = GCTR K(J0, S) over the additional data
+// and the ciphertext.
+func (g *gcmImpl) tag(t, h, preCounterBlock *[blockSize]byte, additionalData, ciphertext []byte) {
+	// S = GHASH H (A || 0 v || C || 0 u || [len(A)] 64 || [len(C)] 64).
+	var s, p [blockSize]byte
+	ghash.Ghash(&s, h, additionalData)
+	ghash.Ghash(&s, h, ciphertext)
+	binary.BigEndian.PutUint32(p[4:], uint32(len(additionalData))<<3)
+	binary.BigEndian.PutUint32(p[12:], uint32(len(ciphertext))<<3)
+	ghash.Ghash(&s, h, p[:])
+
+	for i, v := range preCounterBlock {
+		t[i] = s[i] ^ v
+	}
+}
+
 func (g *gcmImpl) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
 	if len(nonce) != g.nonceSize {
 		panic("bsaes/gcmImpl.Seal: nonce with invalid size provided")
@@ -126,18 +142,10 @@ func (g *gcmImpl) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
 	// Let C=GCTR K(inc32(J0), P).
 	g.gctr(&j, out, plaintext)
 
-	// S = GHASH H (A || 0 v || C || 0 u || [len(A)] 64 || [len(C)] 64).
-	var s, p [blockSize]byte
-	ghash.Ghash(&s, &h, additionalData)
-	ghash.Ghash(&s, &h, out[:sz])
-	binary.BigEndian.PutUint32(p[4:], uint32(len(additionalData))<<3)
-	binary.BigEndian.PutUint32(p[12:], uint32(sz)<<3)
-	ghash.Ghash(&s, &h, p[:])
-
 	// Let T = MSB t(GCTR K(J0, S))
-	for i, v := range preCounterBlock {
-		out[sz+i] = s[i] ^ v
-	}
+	var s [blockSize]byte
+	g.tag(&s, &h, &preCounterBlock, additionalData, out[:sz])
+	copy(out[sz:], s[:])
 
 	dst = append(dst, out...)
 	return dst
@@ -163,17 +171,8 @@ func (g *gcmImpl) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, e
 	var h, j, preCounterBlock [blockSize]byte
 	g.deriveNonceVals(&h, &j, &preCounterBlock, nonce)
 
-	// S = GHASH H (A || 0 v || C || 0 u || [len(A)] 64 || [len(C)] 64).
-	var s, p [blockSize]byte
-	ghash.Ghash(&s, &h, additionalData)
-	ghash.Ghash(&s, &h, ciphertext[:sz])
-	binary.BigEndian.PutUint32(p[4:], uint32(len(additionalData))<<3)
-	binary.BigEndian.PutUint32(p[12:], uint32(sz)<<3)
-	ghash.Ghash(&s, &h, p[:])
-	for i, v := range preCounterBlock {
-		s[i] ^= v
-	}
-
+	var s [blockSize]byte
+	g.tag(&s, &h, &preCounterBlock, additionalData, ciphertext[:sz])
 	if subtle.ConstantTimeCompare(s[:], ciphertext[sz:]) != 1 {
 		return nil, errFail
 	}
@@ -190,7 +189,7 @@ func inc32(ctr *[blockSize]byte) {
 	binary.BigEndian.PutUint32(ctr[12:], v)
 }
 
-func newGCMImpl(ecb bulkECBAble, size int) cipher.AEAD {
+func newGCMImpl(ecb bulkECBAble, size int) *gcmImpl {
 	g := new(gcmImpl)
 	g.ecb = ecb
 	g.nonceSize = size
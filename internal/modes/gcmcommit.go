@@ -0,0 +1,148 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package modes
+
+import (
+	"crypto/cipher"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"runtime"
+)
+
+const gcmCommitmentSize = sha256.Size
+
+// NewCommittingGCM returns a key-committing AES-GCM cipher.AEAD instance,
+// with the AES instance b, keyed with key.
+//
+// This is the CTX construction of Chan and Rogaway, instantiated with
+// AES-GCM with a 96 bit nonce and SHA-256.  The output is C || T*, where C is
+// the AES-GCM ciphertext, and the AES-GCM tag T is replaced by:
+//
+//	T* = SHA-256(BE64(len(K)) || K || BE64(len(N)) || N || BE64(len(A)) || A || T)
+//
+// Opening recomputes T from C and A, and compares T* in constant time before
+// decrypting.  T is never output.
+func NewCommittingGCM(b cipher.Block, key []byte) (cipher.AEAD, error) {
+	if b.BlockSize() != blockSize {
+		return nil, errors.New("bsaes/NewCommittingGCM: GCM requires 128 bit block sizes")
+	}
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		return nil, errors.New("bsaes/NewCommittingGCM: invalid key size")
+	}
+
+	c := new(gcmCommitImpl)
+	c.gcm = newGCMImpl(toBulkECB(b), gcmNonceSize)
+	c.key = append([]byte{}, key...)
+
+	runtime.SetFinalizer(c, (*gcmCommitImpl).Reset)
+
+	return c, nil
+}
+
+type gcmCommitImpl struct {
+	gcm *gcmImpl
+	key []byte
+}
+
+func (c *gcmCommitImpl) NonceSize() int {
+	return gcmNonceSize
+}
+
+func (c *gcmCommitImpl) Overhead() int {
+	return gcmCommitmentSize
+}
+
+func (c *gcmCommitImpl) Reset() {
+	memwipe(c.key)
+}
+
+func (c *gcmCommitImpl) commitment(out, nonce, additionalData []byte, tag *[blockSize]byte) {
+	var l [8]byte
+
+	h := sha256.New()
+	for _, v := range [][]byte{c.key, nonce, additionalData} {
+		binary.BigEndian.PutUint64(l[:], uint64(len(v)))
+		h.Write(l[:])
+		h.Write(v)
+	}
+	h.Write(tag[:])
+	h.Sum(out[:0])
+}
+
+func (c *gcmCommitImpl) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != gcmNonceSize {
+		panic("bsaes/gcmCommitImpl.Seal: nonce with invalid size provided")
+	}
+
+	sz := len(plaintext)
+	if uint64(sz) > 0xfffffffe0 { // len(P) <= 2^39 - 256 (bits)
+		panic("bsaes/gcmCommitImpl.Seal: plaintext too large")
+	}
+	out := make([]byte, sz+gcmCommitmentSize)
+
+	var h, j, preCounterBlock, tag [blockSize]byte
+	c.gcm.deriveNonceVals(&h, &j, &preCounterBlock, nonce)
+	c.gcm.gctr(&j, out, plaintext)
+	c.gcm.tag(&tag, &h, &preCounterBlock, additionalData, out[:sz])
+	c.commitment(out[sz:], nonce, additionalData, &tag)
+	memwipe(tag[:])
+
+	dst = append(dst, out...)
+	return dst
+}
+
+func (c *gcmCommitImpl) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != gcmNonceSize {
+		panic("bsaes/gcmCommitImpl.Open: nonce with invalid size provided")
+	}
+
+	sz := len(ciphertext)
+	if sz < gcmCommitmentSize {
+		return nil, errFail
+	}
+	sz -= gcmCommitmentSize
+	if uint64(sz) > 0xfffffffe0 {
+		return nil, errFail
+	}
+
+	var h, j, preCounterBlock, tag [blockSize]byte
+	c.gcm.deriveNonceVals(&h, &j, &preCounterBlock, nonce)
+	c.gcm.tag(&tag, &h, &preCounterBlock, additionalData, ciphertext[:sz])
+
+	var expected [gcmCommitmentSize]byte
+	c.commitment(expected[:], nonce, additionalData, &tag)
+	memwipe(tag[:])
+	if subtle.ConstantTimeCompare(expected[:], ciphertext[sz:]) != 1 {
+		return nil, errFail
+	}
+
+	out := make([]byte, sz)
+	c.gcm.gctr(&j, out, ciphertext[:sz])
+	dst = append(dst, out...)
+
+	return dst, nil
+}
@@ -0,0 +1,209 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package modes
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+
+	"git.schwanenlied.me/yawning/bsaes.git/ghash"
+)
+
+const (
+	gcmSIVNonceSize = 96 / 8
+	gcmSIVTagSize   = 16
+	gcmSIVMaxSize   = 1 << 36
+)
+
+// NewGCMSIV returns an AES-GCM-SIV (RFC 8452) cipher.AEAD instance, with the
+// key-generating key b, of keySize bytes (16 or 32).
+func NewGCMSIV(b cipher.Block, keySize int) (cipher.AEAD, error) {
+	if b.BlockSize() != blockSize {
+		return nil, errors.New("bsaes/NewGCMSIV: GCM-SIV requires 128 bit block sizes")
+	}
+	switch keySize {
+	case 16, 32:
+	default:
+		return nil, errors.New("bsaes/NewGCMSIV: invalid key size")
+	}
+
+	g := new(gcmSIVImpl)
+	g.b = b
+	g.ecb = toBulkECB(b)
+	g.keySize = keySize
+	return g, nil
+}
+
+type gcmSIVImpl struct {
+	b   cipher.Block
+	ecb bulkECBAble
+
+	keySize int
+}
+
+func (g *gcmSIVImpl) NonceSize() int {
+	return gcmSIVNonceSize
+}
+
+func (g *gcmSIVImpl) Overhead() int {
+	return gcmSIVTagSize
+}
+
+func (g *gcmSIVImpl) deriveKeys(authKey *[blockSize]byte, nonce []byte) bulkECBAble {
+	// Each derivation block is LE32(i) || nonce, and only the first 8 bytes
+	// of each of the encrypted blocks are used.
+	var buf [6 * blockSize]byte
+	nBlocks := 2 + g.keySize/8
+	for i := 0; i < nBlocks; i++ {
+		blk := buf[i*blockSize:]
+		binary.LittleEndian.PutUint32(blk[:], uint32(i))
+		copy(blk[4:blockSize], nonce)
+	}
+	ecbEncryptBlocks(g.ecb, buf[:nBlocks*blockSize])
+
+	var encKey [32]byte
+	copy(authKey[0:], buf[0:8])
+	copy(authKey[8:], buf[blockSize:blockSize+8])
+	for i := 2; i < nBlocks; i++ {
+		copy(encKey[(i-2)*8:], buf[i*blockSize:i*blockSize+8])
+	}
+	enc := newCipher(g.b, encKey[:g.keySize])
+	memwipe(buf[:])
+	memwipe(encKey[:])
+
+	return enc
+}
+
+func (g *gcmSIVImpl) tag(tag *[blockSize]byte, enc bulkECBAble, authKey *[blockSize]byte, nonce, plaintext, additionalData []byte) {
+	// S_s = POLYVAL(auth_key, pad(AD) || pad(P) || length_block)
+	var s, p [blockSize]byte
+	ghash.Polyval(&s, authKey, additionalData)
+	ghash.Polyval(&s, authKey, plaintext)
+	binary.LittleEndian.PutUint64(p[0:], uint64(len(additionalData))<<3)
+	binary.LittleEndian.PutUint64(p[8:], uint64(len(plaintext))<<3)
+	ghash.Polyval(&s, authKey, p[:])
+
+	for i, v := range nonce {
+		s[i] ^= v
+	}
+	s[blockSize-1] &= 0x7f
+	enc.Encrypt(tag[:], s[:])
+}
+
+func (g *gcmSIVImpl) ctr(enc bulkECBAble, tag *[blockSize]byte, dst, src []byte) {
+	var ctr [blockSize]byte
+	copy(ctr[:], tag[:])
+	ctr[blockSize-1] |= 0x80
+
+	stride := enc.Stride()
+	idx := stride * blockSize
+	buf := make([]byte, stride*blockSize)
+
+	for len(src) > 0 {
+		if idx >= len(buf) {
+			// The counter is the little endian 32 bit value in the first
+			// 4 bytes of the block, and wraps without carry.
+			for i := 0; i < stride; i++ {
+				copy(buf[i*blockSize:], ctr[:])
+				v := binary.LittleEndian.Uint32(ctr[:]) + 1
+				binary.LittleEndian.PutUint32(ctr[:], v)
+			}
+			enc.BulkEncrypt(buf, buf)
+			idx = 0
+		}
+
+		n := len(buf) - idx
+		if sLen := len(src); sLen < n {
+			n = sLen
+		}
+		for i, v := range src[:n] {
+			dst[i] = v ^ buf[idx+i]
+		}
+
+		dst, src = dst[n:], src[n:]
+		idx += n
+	}
+
+	memwipe(buf)
+}
+
+func (g *gcmSIVImpl) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != gcmSIVNonceSize {
+		panic("bsaes/gcmSIVImpl.Seal: nonce with invalid size provided")
+	}
+	if uint64(len(plaintext)) > gcmSIVMaxSize {
+		panic("bsaes/gcmSIVImpl.Seal: plaintext too large")
+	}
+	if uint64(len(additionalData)) > gcmSIVMaxSize {
+		panic("bsaes/gcmSIVImpl.Seal: additional data too large")
+	}
+
+	var authKey, tag [blockSize]byte
+	enc := g.deriveKeys(&authKey, nonce)
+	defer enc.Reset()
+
+	sz := len(plaintext)
+	out := make([]byte, sz+gcmSIVTagSize)
+	g.tag(&tag, enc, &authKey, nonce, plaintext, additionalData)
+	g.ctr(enc, &tag, out, plaintext)
+	copy(out[sz:], tag[:])
+	memwipe(authKey[:])
+
+	dst = append(dst, out...)
+	return dst
+}
+
+func (g *gcmSIVImpl) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != gcmSIVNonceSize {
+		panic("bsaes/gcmSIVImpl.Open: nonce with invalid size provided")
+	}
+
+	sz := len(ciphertext)
+	if sz < gcmSIVTagSize {
+		return nil, errFail
+	}
+	sz -= gcmSIVTagSize
+	if uint64(sz) > gcmSIVMaxSize || uint64(len(additionalData)) > gcmSIVMaxSize {
+		return nil, errFail
+	}
+
+	var authKey, tag, expectedTag [blockSize]byte
+	enc := g.deriveKeys(&authKey, nonce)
+	defer enc.Reset()
+
+	copy(tag[:], ciphertext[sz:])
+	out := make([]byte, sz)
+	g.ctr(enc, &tag, out, ciphertext[:sz])
+	g.tag(&expectedTag, enc, &authKey, nonce, out, additionalData)
+	memwipe(authKey[:])
+
+	if subtle.ConstantTimeCompare(tag[:], expectedTag[:]) != 1 {
+		memwipe(out)
+		return nil, errFail
+	}
+
+	dst = append(dst, out...)
+	return dst, nil
+}
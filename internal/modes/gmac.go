@@ -0,0 +1,199 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package modes
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"runtime"
+
+	"git.schwanenlied.me/yawning/bsaes.git/ghash"
+)
+
+// NewGMAC returns a GMAC (SP 800-38D) hash.Hash instance, with the block
+// cipher b, and the nonce.  The digest is identical to the tag produced by
+// GCM with the same nonce, with the message as the additional data, and an
+// empty plaintext.
+func NewGMAC(b cipher.Block, nonce []byte) (hash.Hash, error) {
+	if b.BlockSize() != blockSize {
+		return nil, errors.New("bsaes/NewGMAC: GMAC requires 128 bit block sizes")
+	}
+	if len(nonce) == 0 {
+		return nil, errors.New("bsaes/NewGMAC: invalid nonce size")
+	}
+
+	var j [blockSize]byte
+	g := new(gmacImpl)
+	gcm := newGCMImpl(toBulkECB(b), len(nonce))
+	gcm.deriveNonceVals(&g.h, &j, &g.preCounterBlock, nonce)
+	memwipe(j[:])
+
+	runtime.SetFinalizer(g, (*gmacImpl).wipe)
+
+	return g, nil
+}
+
+// NewGMACAEAD returns a GMAC (SP 800-38D) cipher.AEAD instance, with the
+// block cipher b, and a nonceSize byte nonce.  Only the additional data is
+// authenticated, and the plaintext must be empty, with the output being the
+// tag that GCM would produce.
+func NewGMACAEAD(b cipher.Block, nonceSize int) (cipher.AEAD, error) {
+	if b.BlockSize() != blockSize {
+		return nil, errors.New("bsaes/NewGMACAEAD: GMAC requires 128 bit block sizes")
+	}
+	if nonceSize <= 0 {
+		return nil, errors.New("bsaes/NewGMACAEAD: invalid nonce size")
+	}
+
+	g := new(gmacAEADImpl)
+	g.gcm = newGCMImpl(toBulkECB(b), nonceSize)
+
+	return g, nil
+}
+
+type gmacAEADImpl struct {
+	gcm *gcmImpl
+}
+
+func (g *gmacAEADImpl) NonceSize() int {
+	return g.gcm.nonceSize
+}
+
+func (g *gmacAEADImpl) Overhead() int {
+	return gcmTagSize
+}
+
+func (g *gmacAEADImpl) tag(t *[blockSize]byte, nonce, additionalData []byte) {
+	var h, j, preCounterBlock [blockSize]byte
+	g.gcm.deriveNonceVals(&h, &j, &preCounterBlock, nonce)
+	g.gcm.tag(t, &h, &preCounterBlock, additionalData, nil)
+	memwipe(h[:])
+	memwipe(preCounterBlock[:])
+}
+
+func (g *gmacAEADImpl) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != g.gcm.nonceSize {
+		panic("bsaes/gmacAEADImpl.Seal: nonce with invalid size provided")
+	}
+	if len(plaintext) != 0 {
+		panic("bsaes/gmacAEADImpl.Seal: non-empty plaintext provided")
+	}
+
+	var t [blockSize]byte
+	g.tag(&t, nonce, additionalData)
+	dst = append(dst, t[:]...)
+	return dst
+}
+
+func (g *gmacAEADImpl) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != g.gcm.nonceSize {
+		panic("bsaes/gmacAEADImpl.Open: nonce with invalid size provided")
+	}
+	if len(ciphertext) != gcmTagSize {
+		return nil, errFail
+	}
+
+	var t [blockSize]byte
+	g.tag(&t, nonce, additionalData)
+	if subtle.ConstantTimeCompare(t[:], ciphertext) != 1 {
+		return nil, errFail
+	}
+
+	return dst, nil
+}
+
+// gmacImpl is a constant time GMAC implementation, built on the GCM nonce
+// processing, and GHASH.
+type gmacImpl struct {
+	h               [blockSize]byte
+	preCounterBlock [blockSize]byte
+
+	s   [blockSize]byte
+	buf [blockSize]byte
+	n   int
+	l   uint64
+}
+
+func (g *gmacImpl) Size() int {
+	return gcmTagSize
+}
+
+func (g *gmacImpl) BlockSize() int {
+	return blockSize
+}
+
+func (g *gmacImpl) Reset() {
+	memwipe(g.s[:])
+	memwipe(g.buf[:])
+	g.n = 0
+	g.l = 0
+}
+
+func (g *gmacImpl) Write(p []byte) (int, error) {
+	pLen := len(p)
+	g.l += uint64(pLen)
+
+	// Complete any buffered partial block first.
+	if g.n > 0 {
+		n := copy(g.buf[g.n:], p)
+		g.n += n
+		p = p[n:]
+		if g.n < blockSize {
+			return pLen, nil
+		}
+		ghash.Ghash(&g.s, &g.h, g.buf[:])
+		g.n = 0
+	}
+
+	// Process as many complete blocks as possible, and buffer the rest.
+	full := len(p) &^ (blockSize - 1)
+	ghash.Ghash(&g.s, &g.h, p[:full])
+	g.n = copy(g.buf[:], p[full:])
+
+	return pLen, nil
+}
+
+func (g *gmacImpl) Sum(b []byte) []byte {
+	// S = GHASH_H(A || 0^v || [len(A)]_64 || [0]_64)
+	// T = GCTR_K(J0, S)
+	s := g.s
+	ghash.Ghash(&s, &g.h, g.buf[:g.n])
+
+	var p [blockSize]byte
+	binary.BigEndian.PutUint64(p[:], g.l<<3)
+	ghash.Ghash(&s, &g.h, p[:])
+	xorBytes(s[:], s[:], g.preCounterBlock[:])
+
+	b = append(b, s[:]...)
+	memwipe(s[:])
+	return b
+}
+
+func (g *gmacImpl) wipe() {
+	g.Reset()
+	memwipe(g.h[:])
+	memwipe(g.preCounterBlock[:])
+}
@@ -0,0 +1,200 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package modes
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"runtime"
+
+	"git.schwanenlied.me/yawning/bsaes.git/ghash"
+)
+
+// HCTR2 is a HCTR2 length-preserving tweakable wide-block cipher instance.
+type HCTR2 struct {
+	ecb bulkECBAble
+	h   [blockSize]byte
+	l   [blockSize]byte
+
+	stride int
+}
+
+// NewHCTR2 returns a HCTR2 instance, with the block cipher b.
+func NewHCTR2(b cipher.Block) (*HCTR2, error) {
+	if b.BlockSize() != blockSize {
+		return nil, errors.New("bsaes/NewHCTR2: HCTR2 requires 128 bit block sizes")
+	}
+
+	x := new(HCTR2)
+	x.ecb = toBulkECB(b)
+	x.stride = x.ecb.Stride()
+
+	// h = E_K(bin(0)), L = E_K(bin(1))
+	x.l[0] = 1
+	x.ecb.Encrypt(x.h[:], x.h[:])
+	x.ecb.Encrypt(x.l[:], x.l[:])
+
+	runtime.SetFinalizer(x, (*HCTR2).Reset)
+
+	return x, nil
+}
+
+// Reset clears the derived keys such that they no longer appear in process
+// memory.  It does not clear the underlying block cipher.
+func (x *HCTR2) Reset() {
+	memwipe(x.h[:])
+	memwipe(x.l[:])
+}
+
+// Encrypt encrypts src with the tweak, and places the resulting output in
+// dst.  src must be at least 16 bytes long.
+func (x *HCTR2) Encrypt(dst, src, tweak []byte) {
+	x.checkSizes(dst, src)
+
+	// MM = M ^ H(T, N)
+	// UU = E_K(MM)
+	// S = MM ^ UU ^ L
+	// V = N ^ XCTR(S)
+	// U = UU ^ H(T, V)
+	var t, mm, uu, s [blockSize]byte
+	x.hashTweak(&t, tweak, len(src))
+
+	x.hash(&mm, &t, src[blockSize:])
+	xorBytes(mm[:], mm[:], src[:blockSize])
+	x.ecb.Encrypt(uu[:], mm[:])
+	xorBytes(s[:], mm[:], uu[:])
+	xorBytes(s[:], s[:], x.l[:])
+	x.xctr(dst[blockSize:], src[blockSize:], &s)
+	x.hash(&mm, &t, dst[blockSize:len(src)])
+	xorBytes(dst[:blockSize], mm[:], uu[:])
+
+	memwipe(mm[:])
+	memwipe(uu[:])
+	memwipe(s[:])
+}
+
+// Decrypt decrypts src with the tweak, and places the resulting output in
+// dst.  src must be at least 16 bytes long.
+func (x *HCTR2) Decrypt(dst, src, tweak []byte) {
+	x.checkSizes(dst, src)
+
+	// UU = U ^ H(T, V)
+	// MM = D_K(UU)
+	// S = MM ^ UU ^ L
+	// N = V ^ XCTR(S)
+	// M = MM ^ H(T, N)
+	var t, mm, uu, s [blockSize]byte
+	x.hashTweak(&t, tweak, len(src))
+
+	x.hash(&uu, &t, src[blockSize:])
+	xorBytes(uu[:], uu[:], src[:blockSize])
+	x.ecb.Decrypt(mm[:], uu[:])
+	xorBytes(s[:], mm[:], uu[:])
+	xorBytes(s[:], s[:], x.l[:])
+	x.xctr(dst[blockSize:], src[blockSize:], &s)
+	x.hash(&uu, &t, dst[blockSize:len(src)])
+	xorBytes(dst[:blockSize], mm[:], uu[:])
+
+	memwipe(mm[:])
+	memwipe(uu[:])
+	memwipe(s[:])
+}
+
+func (x *HCTR2) checkSizes(dst, src []byte) {
+	if len(src) < blockSize {
+		panic("bsaes/HCTR2: message too short")
+	}
+	if len(dst) < len(src) {
+		panic("bsaes/HCTR2: output smaller than input")
+	}
+}
+
+// hashTweak absorbs the tweak length block and the padded tweak into the
+// POLYVAL state t, which is shared by both hashes of a message.
+func (x *HCTR2) hashTweak(t *[blockSize]byte, tweak []byte, msgLen int) {
+	// The tweak length block is bin(2 * |T| + 2), or bin(2 * |T| + 3) if
+	// the variable length part of the message is not block aligned.
+	var lenBlock [blockSize]byte
+	v := uint64(len(tweak)) << 4
+	if msgLen%blockSize == 0 {
+		v += 2
+	} else {
+		v += 3
+	}
+	binary.LittleEndian.PutUint64(lenBlock[:], v)
+
+	ghash.Polyval(t, &x.h, lenBlock[:])
+	ghash.Polyval(t, &x.h, tweak)
+}
+
+// hash calculates H(T, N) with the tweak state t, and stores the result in
+// y.
+func (x *HCTR2) hash(y, t *[blockSize]byte, n []byte) {
+	*y = *t
+
+	full := len(n) &^ (blockSize - 1)
+	ghash.Polyval(y, &x.h, n[:full])
+	if r := len(n) - full; r != 0 {
+		// pad(N || 1)
+		var tmp [blockSize]byte
+		copy(tmp[:], n[full:])
+		tmp[r] = 1
+		ghash.Polyval(y, &x.h, tmp[:])
+		memwipe(tmp[:])
+	}
+}
+
+// xctr XORs src with the XCTR keystream for the nonce s, and places the
+// resulting output in dst.
+func (x *HCTR2) xctr(dst, src []byte, s *[blockSize]byte) {
+	var ctr uint64
+	idx := x.stride * blockSize
+	buf := make([]byte, x.stride*blockSize)
+
+	// XCTR(S)_i = E_K(S ^ bin(i)), for i = 1, 2, ...
+	for len(src) > 0 {
+		if idx >= len(buf) {
+			for i := 0; i < x.stride; i++ {
+				ctr++
+				blk := buf[i*blockSize : (i+1)*blockSize]
+				copy(blk, s[:])
+				v := binary.LittleEndian.Uint64(blk) ^ ctr
+				binary.LittleEndian.PutUint64(blk, v)
+			}
+			x.ecb.BulkEncrypt(buf, buf)
+			idx = 0
+		}
+
+		n := len(buf) - idx
+		if sLen := len(src); sLen < n {
+			n = sLen
+		}
+		xorBytes(dst[:n], src[:n], buf[idx:])
+
+		dst, src = dst[n:], src[n:]
+		idx += n
+	}
+
+	memwipe(buf)
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package modes
+
+import "crypto/cipher"
+
+// NewIGE returns an IGE (Infinite Garble Extension) cipher.BlockMode, which
+// encrypts or decrypts with the given cipher.Block.  The iv is the
+// concatenation of the initial previous ciphertext and plaintext blocks, as
+// in OpenSSL.
+//
+// Unlike CBC, the block cipher input for both directions depends on the
+// output of the previous block cipher invocation, so there is nothing that
+// can be batched, and each block is processed with a single call.
+func NewIGE(b cipher.Block, iv []byte, decrypt bool) cipher.BlockMode {
+	if b.BlockSize() != blockSize || len(iv) != 2*blockSize {
+		panic("bsaes/NewIGE: iv size must be twice the block size")
+	}
+
+	c := new(igeImpl)
+	c.b = b
+	c.decrypt = decrypt
+	copy(c.prevC[:], iv[:blockSize])
+	copy(c.prevP[:], iv[blockSize:])
+
+	return c
+}
+
+type igeImpl struct {
+	b     cipher.Block
+	prevC [blockSize]byte
+	prevP [blockSize]byte
+
+	decrypt bool
+}
+
+func (c *igeImpl) BlockSize() int {
+	return blockSize
+}
+
+func (c *igeImpl) CryptBlocks(dst, src []byte) {
+	if len(src)%blockSize != 0 {
+		panic("bsaes/igeImpl.CryptBlocks: input not full blocks")
+	}
+	if len(dst) < len(src) {
+		panic("bsaes/igeImpl.CryptBlocks: output smaller than input")
+	}
+
+	// The roles of the chaining values swap depending on the direction:
+	//
+	//   Encrypt: y_i = E_K(x_i xor y_{i-1}) xor x_{i-1}
+	//   Decrypt: x_i = D_K(y_i xor x_{i-1}) xor y_{i-1}
+	pre, post := &c.prevC, &c.prevP
+	if c.decrypt {
+		pre, post = post, pre
+	}
+
+	var in, out [blockSize]byte
+	for len(src) > 0 {
+		copy(in[:], src[:blockSize])
+
+		xorBytes(out[:], in[:], pre[:])
+		if c.decrypt {
+			c.b.Decrypt(out[:], out[:])
+		} else {
+			c.b.Encrypt(out[:], out[:])
+		}
+		xorBytes(out[:], out[:], post[:])
+		copy(dst, out[:])
+
+		*pre, *post = out, in
+		dst, src = dst[blockSize:], src[blockSize:]
+	}
+	memwipe(in[:])
+	memwipe(out[:])
+}
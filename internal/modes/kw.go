@@ -0,0 +1,220 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package modes
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+)
+
+const kwSemiblockSize = 8
+
+var (
+	kwDefaultICV  = []byte{0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6}
+	kwpDefaultICV = []byte{0xa6, 0x59, 0x59, 0xa6}
+
+	errKWInvalidICV = errors.New("bsaes: invalid key wrap ICV")
+	errKWIntegrity  = errors.New("bsaes: key unwrap integrity check failed")
+)
+
+// Wrap wraps plaintext with the KW (RFC 3394, SP 800-38F) algorithm, with
+// the 8 byte ICV (or the default ICV if nil).
+func Wrap(kek cipher.Block, icv, plaintext []byte) ([]byte, error) {
+	if kek.BlockSize() != blockSize {
+		return nil, errors.New("bsaes/Wrap: KW requires 128 bit block sizes")
+	}
+	if icv == nil {
+		icv = kwDefaultICV
+	}
+	if len(icv) != kwSemiblockSize {
+		return nil, errKWInvalidICV
+	}
+	sz := len(plaintext)
+	if sz < 2*kwSemiblockSize || sz%kwSemiblockSize != 0 {
+		return nil, errors.New("bsaes/Wrap: invalid plaintext length")
+	}
+
+	out := make([]byte, kwSemiblockSize+sz)
+	copy(out, icv)
+	copy(out[kwSemiblockSize:], plaintext)
+	kwWrap(kek, out)
+
+	return out, nil
+}
+
+// Unwrap unwraps ciphertext with the KW (RFC 3394, SP 800-38F) algorithm,
+// with the 8 byte ICV (or the default ICV if nil).
+func Unwrap(kek cipher.Block, icv, ciphertext []byte) ([]byte, error) {
+	if kek.BlockSize() != blockSize {
+		return nil, errors.New("bsaes/Unwrap: KW requires 128 bit block sizes")
+	}
+	if icv == nil {
+		icv = kwDefaultICV
+	}
+	if len(icv) != kwSemiblockSize {
+		return nil, errKWInvalidICV
+	}
+	sz := len(ciphertext)
+	if sz < 3*kwSemiblockSize || sz%kwSemiblockSize != 0 {
+		return nil, errKWIntegrity
+	}
+
+	buf := make([]byte, sz)
+	copy(buf, ciphertext)
+	kwUnwrap(kek, buf)
+
+	if subtle.ConstantTimeCompare(buf[:kwSemiblockSize], icv) != 1 {
+		memwipe(buf)
+		return nil, errKWIntegrity
+	}
+
+	return buf[kwSemiblockSize:], nil
+}
+
+// WrapPad wraps plaintext with the KWP (RFC 5649, SP 800-38F) algorithm,
+// with the 4 byte ICV (or the default ICV if nil).
+func WrapPad(kek cipher.Block, icv, plaintext []byte) ([]byte, error) {
+	if kek.BlockSize() != blockSize {
+		return nil, errors.New("bsaes/WrapPad: KWP requires 128 bit block sizes")
+	}
+	if icv == nil {
+		icv = kwpDefaultICV
+	}
+	if len(icv) != kwSemiblockSize/2 {
+		return nil, errKWInvalidICV
+	}
+	sz := len(plaintext)
+	if sz < 1 || uint64(sz) > 0xffffffff {
+		return nil, errors.New("bsaes/WrapPad: invalid plaintext length")
+	}
+
+	// The plaintext is zero padded to a multiple of the semiblock size, and
+	// the AIV is ICV || [len(P)]_32.
+	padSz := (sz + kwSemiblockSize - 1) &^ (kwSemiblockSize - 1)
+	out := make([]byte, kwSemiblockSize+padSz)
+	copy(out, icv)
+	binary.BigEndian.PutUint32(out[4:], uint32(sz))
+	copy(out[kwSemiblockSize:], plaintext)
+
+	if padSz == kwSemiblockSize {
+		kek.Encrypt(out, out)
+	} else {
+		kwWrap(kek, out)
+	}
+
+	return out, nil
+}
+
+// UnwrapPad unwraps ciphertext with the KWP (RFC 5649, SP 800-38F)
+// algorithm, with the 4 byte ICV (or the default ICV if nil).
+func UnwrapPad(kek cipher.Block, icv, ciphertext []byte) ([]byte, error) {
+	if kek.BlockSize() != blockSize {
+		return nil, errors.New("bsaes/UnwrapPad: KWP requires 128 bit block sizes")
+	}
+	if icv == nil {
+		icv = kwpDefaultICV
+	}
+	if len(icv) != kwSemiblockSize/2 {
+		return nil, errKWInvalidICV
+	}
+	sz := len(ciphertext)
+	if sz < 2*kwSemiblockSize || sz%kwSemiblockSize != 0 {
+		return nil, errKWIntegrity
+	}
+
+	buf := make([]byte, sz)
+	if sz == 2*kwSemiblockSize {
+		kek.Decrypt(buf, ciphertext)
+	} else {
+		copy(buf, ciphertext)
+		kwUnwrap(kek, buf)
+	}
+
+	// Validate the ICV, the length, and the padding without branching on
+	// any of them.
+	padSz := sz - kwSemiblockSize
+	mli := binary.BigEndian.Uint32(buf[4:])
+	ok := subtle.ConstantTimeCompare(buf[:4], icv)
+
+	// 8*(n-1) < MLI <= 8*n
+	d := (uint64(mli) - uint64(padSz-kwSemiblockSize+1)) >> 3
+	ok &= int(1 ^ ((d | -d) >> 63))
+
+	var padOr byte
+	for i, v := range buf[kwSemiblockSize:] {
+		// Only bytes past the encoded length count towards the padding.
+		isPad := 1 ^ byte((uint64(i)-uint64(mli))>>63)
+		padOr |= v & -isPad
+	}
+	ok &= subtle.ConstantTimeByteEq(padOr, 0)
+
+	if ok != 1 {
+		memwipe(buf)
+		return nil, errKWIntegrity
+	}
+
+	return buf[kwSemiblockSize : kwSemiblockSize+int(mli)], nil
+}
+
+func kwWrap(kek cipher.Block, buf []byte) {
+	var b [blockSize]byte
+	n := len(buf)/kwSemiblockSize - 1
+
+	// A = buf[0:8], R[i] = buf[8*i:8*(i+1)]
+	for j := 0; j < 6; j++ {
+		for i := 1; i <= n; i++ {
+			// B = AES(K, A | R[i]), A = MSB(64, B) ^ t, R[i] = LSB(64, B)
+			r := buf[i*kwSemiblockSize : (i+1)*kwSemiblockSize]
+			copy(b[:], buf[:kwSemiblockSize])
+			copy(b[kwSemiblockSize:], r)
+			kek.Encrypt(b[:], b[:])
+
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(buf, binary.BigEndian.Uint64(b[:])^t)
+			copy(r, b[kwSemiblockSize:])
+		}
+	}
+	memwipe(b[:])
+}
+
+func kwUnwrap(kek cipher.Block, buf []byte) {
+	var b [blockSize]byte
+	n := len(buf)/kwSemiblockSize - 1
+
+	for j := 5; j >= 0; j-- {
+		for i := n; i >= 1; i-- {
+			// B = AES-1(K, (A ^ t) | R[i]), A = MSB(64, B), R[i] = LSB(64, B)
+			r := buf[i*kwSemiblockSize : (i+1)*kwSemiblockSize]
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(b[:], binary.BigEndian.Uint64(buf)^t)
+			copy(b[kwSemiblockSize:], r)
+			kek.Decrypt(b[:], b[:])
+
+			copy(buf, b[:kwSemiblockSize])
+			copy(r, b[kwSemiblockSize:])
+		}
+	}
+	memwipe(b[:])
+}
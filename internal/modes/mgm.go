@@ -0,0 +1,214 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package modes
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+
+	"git.schwanenlied.me/yawning/bsaes.git/ghash"
+)
+
+const mgmNonceSize = blockSize
+
+// NewMGM returns a Multilinear Galois Mode (RFC 9058) cipher.AEAD instance,
+// with the specified tag size (4 to 16 bytes).
+func NewMGM(b cipher.Block, tagSize int) (cipher.AEAD, error) {
+	if b.BlockSize() != blockSize {
+		return nil, errors.New("bsaes/NewMGM: MGM requires 128 bit block sizes")
+	}
+	if tagSize < 4 || tagSize > blockSize {
+		return nil, errors.New("bsaes/NewMGM: invalid tag size")
+	}
+
+	m := new(mgmImpl)
+	m.ecb = toBulkECB(b)
+	m.tagSize = tagSize
+	m.stride = m.ecb.Stride()
+	return m, nil
+}
+
+type mgmImpl struct {
+	ecb bulkECBAble
+
+	tagSize int
+	stride  int
+}
+
+func (m *mgmImpl) NonceSize() int {
+	return mgmNonceSize
+}
+
+func (m *mgmImpl) Overhead() int {
+	return m.tagSize
+}
+
+// incrR increments the right (least significant) half of the block.
+func incrR(ctr *[blockSize]byte) {
+	v := binary.BigEndian.Uint64(ctr[8:]) + 1
+	binary.BigEndian.PutUint64(ctr[8:], v)
+}
+
+// incrL increments the left (most significant) half of the block.
+func incrL(ctr *[blockSize]byte) {
+	v := binary.BigEndian.Uint64(ctr[:]) + 1
+	binary.BigEndian.PutUint64(ctr[:], v)
+}
+
+func (m *mgmImpl) deriveCounters(y, z *[blockSize]byte, nonce []byte) {
+	// Y_1 = E_K(0 || ICN), Z_1 = E_K(1 || ICN)
+	var buf [2 * blockSize]byte
+	copy(buf[:], nonce)
+	copy(buf[blockSize:], nonce)
+	buf[blockSize] |= 0x80
+	ecbEncryptBlocks(m.ecb, buf[:])
+	copy(y[:], buf[:blockSize])
+	copy(z[:], buf[blockSize:])
+}
+
+func (m *mgmImpl) ctr(y *[blockSize]byte, dst, src []byte) {
+	idx := m.stride * blockSize
+	buf := make([]byte, m.stride*blockSize)
+
+	// C_i = P_i ^ E_K(Y_i), Y_{i+1} = incr_r(Y_i)
+	for len(src) > 0 {
+		if idx >= len(buf) {
+			for i := 0; i < m.stride; i++ {
+				copy(buf[i*blockSize:], y[:])
+				incrR(y)
+			}
+			m.ecb.BulkEncrypt(buf, buf)
+			idx = 0
+		}
+
+		n := len(buf) - idx
+		if sLen := len(src); sLen < n {
+			n = sLen
+		}
+		xorBytes(dst[:n], src[:n], buf[idx:])
+
+		dst, src = dst[n:], src[n:]
+		idx += n
+	}
+
+	memwipe(buf)
+}
+
+func (m *mgmImpl) mac(sum, z *[blockSize]byte, data []byte) {
+	var a, t [blockSize]byte
+	buf := make([]byte, m.stride*blockSize)
+
+	// sum ^= H_i (x) A_i, H_i = E_K(Z_i), Z_{i+1} = incr_l(Z_i)
+	for len(data) > 0 {
+		n := (len(data) + blockSize - 1) / blockSize
+		if n > m.stride {
+			n = m.stride
+		}
+		sz := n * blockSize
+
+		for i := 0; i < n; i++ {
+			copy(buf[i*blockSize:], z[:])
+			incrL(z)
+		}
+		ecbEncryptBlocks(m.ecb, buf[:sz])
+
+		for i := 0; i < sz; i += blockSize {
+			var h [blockSize]byte
+			copy(h[:], buf[i:])
+			a = [blockSize]byte{}
+			copy(a[:], data)
+			ghash.Mul(&t, &h, &a)
+			xorBytes(sum[:], sum[:], t[:])
+			if len(data) < blockSize {
+				data = nil
+			} else {
+				data = data[blockSize:]
+			}
+		}
+	}
+
+	memwipe(buf)
+}
+
+func (m *mgmImpl) tag(tag *[blockSize]byte, z *[blockSize]byte, additionalData, ciphertext []byte) {
+	// T = MSB_S(E_K(sum(H_i (x) A_i, H_{h+j} (x) C_j, H_{h+q+1} (x) (len(A) || len(C)))))
+	var l [blockSize]byte
+	binary.BigEndian.PutUint64(l[:], uint64(len(additionalData))<<3)
+	binary.BigEndian.PutUint64(l[8:], uint64(len(ciphertext))<<3)
+
+	m.mac(tag, z, additionalData)
+	m.mac(tag, z, ciphertext)
+	m.mac(tag, z, l[:])
+	m.ecb.Encrypt(tag[:], tag[:])
+}
+
+func (m *mgmImpl) checkNonce(nonce []byte, fn string) {
+	if len(nonce) != mgmNonceSize {
+		panic("bsaes/mgmImpl." + fn + ": nonce with invalid size provided")
+	}
+	if nonce[0]&0x80 != 0 {
+		panic("bsaes/mgmImpl." + fn + ": nonce with the most significant bit set provided")
+	}
+}
+
+func (m *mgmImpl) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	m.checkNonce(nonce, "Seal")
+
+	sz := len(plaintext)
+	out := make([]byte, sz+m.tagSize)
+
+	var y, z, tag [blockSize]byte
+	m.deriveCounters(&y, &z, nonce)
+	m.ctr(&y, out, plaintext)
+	m.tag(&tag, &z, additionalData, out[:sz])
+	copy(out[sz:], tag[:])
+
+	dst = append(dst, out...)
+	return dst
+}
+
+func (m *mgmImpl) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	m.checkNonce(nonce, "Open")
+
+	sz := len(ciphertext)
+	if sz < m.tagSize {
+		return nil, errFail
+	}
+	sz -= m.tagSize
+
+	var y, z, tag [blockSize]byte
+	m.deriveCounters(&y, &z, nonce)
+	m.tag(&tag, &z, additionalData, ciphertext[:sz])
+
+	if subtle.ConstantTimeCompare(tag[:m.tagSize], ciphertext[sz:]) != 1 {
+		return nil, errFail
+	}
+
+	out := make([]byte, sz)
+	m.ctr(&y, out, ciphertext[:sz])
+	dst = append(dst, out...)
+
+	return dst, nil
+}
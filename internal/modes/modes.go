@@ -22,7 +22,11 @@
 
 package modes
 
-import "crypto/cipher"
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+)
 
 const blockSize = 16 // Always AES.
 
@@ -46,12 +50,119 @@ type bulkECBAble interface {
 	BulkDecrypt(dst, src []byte)
 }
 
+// ecbAdapter wraps a cipher.Block that does not provide the bulk interface
+// (eg: the runtime's AES-NI backed implementation), and presents it as one
+// with a Stride of 1, so that the modes can be used with any AES instance.
+type ecbAdapter struct {
+	cipher.Block
+}
+
+func (a *ecbAdapter) Stride() int {
+	return 1
+}
+
+func (a *ecbAdapter) Reset() {}
+
+func (a *ecbAdapter) BulkEncrypt(dst, src []byte) {
+	a.Encrypt(dst, src)
+}
+
+func (a *ecbAdapter) BulkDecrypt(dst, src []byte) {
+	a.Decrypt(dst, src)
+}
+
+func toBulkECB(b cipher.Block) bulkECBAble {
+	if ecb, ok := b.(bulkECBAble); ok {
+		return ecb
+	}
+	return &ecbAdapter{b}
+}
+
+// ecbEncryptBlocks encrypts the blocks in buf in place, Stride blocks at a
+// time, with any remaining blocks being processed one at a time.
+func ecbEncryptBlocks(ecb bulkECBAble, buf []byte) {
+	bulkSz := ecb.Stride() * blockSize
+	for len(buf) >= bulkSz {
+		ecb.BulkEncrypt(buf, buf)
+		buf = buf[bulkSz:]
+	}
+	for len(buf) > 0 {
+		ecb.Encrypt(buf, buf)
+		buf = buf[blockSize:]
+	}
+}
+
+// ecbDecryptBlocks decrypts the blocks in buf in place, Stride blocks at a
+// time, with any remaining blocks being processed one at a time.
+func ecbDecryptBlocks(ecb bulkECBAble, buf []byte) {
+	bulkSz := ecb.Stride() * blockSize
+	for len(buf) >= bulkSz {
+		ecb.BulkDecrypt(buf, buf)
+		buf = buf[bulkSz:]
+	}
+	for len(buf) > 0 {
+		ecb.Decrypt(buf, buf)
+		buf = buf[blockSize:]
+	}
+}
+
+// gfDouble multiplies the big endian block b by x in GF(2^128), in
+// constant time.
+func gfDouble(b *[blockSize]byte) {
+	hi := binary.BigEndian.Uint64(b[:])
+	lo := binary.BigEndian.Uint64(b[8:])
+	carry := hi >> 63
+	hi = (hi << 1) | (lo >> 63)
+	lo = (lo << 1) ^ (0x87 & -carry)
+	binary.BigEndian.PutUint64(b[:], hi)
+	binary.BigEndian.PutUint64(b[8:], lo)
+}
+
+func memwipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+func xorBytes(dst, a, b []byte) {
+	for i, v := range a {
+		dst[i] = v ^ b[i]
+	}
+}
+
 // BlockModesImpl is a collection of unexported `crypto/cipher` block cipher
 // mode special case implementations.
 type BlockModesImpl struct {
-	b cipher.Block
+	b    cipher.Block
+	ctor func([]byte) cipher.Block
 }
 
-func (m *BlockModesImpl) Init(b cipher.Block) {
+// Init initializes the BlockModesImpl with the block cipher b, and the
+// constructor used to create it, for modes that need to derive new keys.
+func (m *BlockModesImpl) Init(b cipher.Block, ctor func([]byte) cipher.Block) {
 	m.b = b
+	m.ctor = ctor
+}
+
+func (m *BlockModesImpl) newCipher(key []byte) cipher.Block {
+	return m.ctor(key)
+}
+
+type newCipherAble interface {
+	newCipher(key []byte) cipher.Block
+}
+
+// newCipher returns a new AES cipher.Block with the specified key, using the
+// same implementation as b.
+func newCipher(b cipher.Block, key []byte) bulkECBAble {
+	if c, ok := b.(newCipherAble); ok {
+		return toBulkECB(c.newCipher(key))
+	}
+
+	// Not one of ours, so presumably the runtime implementation.
+	blk, err := aes.NewCipher(key)
+	if err != nil {
+		panic("bsaes: failed to instantiate derived cipher: " + err.Error())
+	}
+	return toBulkECB(blk)
 }
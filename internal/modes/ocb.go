@@ -0,0 +1,306 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package modes
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"errors"
+	"math/bits"
+	"runtime"
+)
+
+const ocbNumL = 64 // Enough for any block index representable in a uint64.
+
+// NewOCB returns an OCB3 (RFC 7253) cipher.AEAD instance, with the specified
+// nonce (1 to 15 bytes), and tag (8, 12, or 16 bytes) sizes.
+func NewOCB(b cipher.Block, nonceSize, tagSize int) (cipher.AEAD, error) {
+	if b.BlockSize() != blockSize {
+		return nil, errors.New("bsaes/NewOCB: OCB requires 128 bit block sizes")
+	}
+	if nonceSize < 1 || nonceSize > blockSize-1 {
+		return nil, errors.New("bsaes/NewOCB: invalid nonce size")
+	}
+	switch tagSize {
+	case 8, 12, 16:
+	default:
+		return nil, errors.New("bsaes/NewOCB: invalid tag size")
+	}
+
+	return newOCBImpl(toBulkECB(b), nonceSize, tagSize), nil
+}
+
+type ocbImpl struct {
+	ecb bulkECBAble
+
+	lStar   [blockSize]byte
+	lDollar [blockSize]byte
+	l       [ocbNumL][blockSize]byte
+
+	nonceSize int
+	tagSize   int
+	stride    int
+}
+
+func (o *ocbImpl) NonceSize() int {
+	return o.nonceSize
+}
+
+func (o *ocbImpl) Overhead() int {
+	return o.tagSize
+}
+
+func (o *ocbImpl) Reset() {
+	memwipe(o.lStar[:])
+	memwipe(o.lDollar[:])
+	for i := range o.l {
+		memwipe(o.l[i][:])
+	}
+}
+
+func (o *ocbImpl) initOffset(offset *[blockSize]byte, nonce []byte) {
+	var n, kTop [blockSize]byte
+	var stretch [blockSize + 8]byte
+
+	// Nonce = num2str(TAGLEN mod 128,7) || zeros(120-bitlen(N)) || 1 || N
+	n[0] = byte((o.tagSize*8)%128) << 1
+	n[blockSize-1-len(nonce)] |= 1
+	copy(n[blockSize-len(nonce):], nonce)
+
+	// bottom = str2num(Nonce[123..128])
+	bottom := uint(n[blockSize-1] & 0x3f)
+
+	// Ktop = ENCIPHER(K, Nonce[1..122] || zeros(6))
+	n[blockSize-1] &= 0xc0
+	o.ecb.Encrypt(kTop[:], n[:])
+
+	// Stretch = Ktop || (Ktop[1..64] xor Ktop[9..72])
+	copy(stretch[:], kTop[:])
+	xorBytes(stretch[blockSize:], kTop[:8], kTop[1:9])
+
+	// Offset_0 = Stretch[1+bottom..128+bottom]
+	byteShift, bitShift := bottom/8, bottom%8
+	for i := range offset {
+		offset[i] = stretch[i+int(byteShift)]<<bitShift | stretch[i+int(byteShift)+1]>>(8-bitShift)
+	}
+
+	memwipe(kTop[:])
+	memwipe(stretch[:])
+}
+
+func (o *ocbImpl) hash(sum *[blockSize]byte, additionalData []byte) {
+	var offset [blockSize]byte
+	buf := make([]byte, o.stride*blockSize)
+	offsets := make([]byte, o.stride*blockSize)
+
+	idx := uint64(0)
+	for len(additionalData) >= blockSize {
+		n := len(additionalData) / blockSize
+		if n > o.stride {
+			n = o.stride
+		}
+		sz := n * blockSize
+
+		// Offset_i = Offset_{i-1} xor L_{ntz(i)}
+		for i := 0; i < n; i++ {
+			idx++
+			xorBytes(offset[:], offset[:], o.l[bits.TrailingZeros64(idx)][:])
+			copy(offsets[i*blockSize:], offset[:])
+		}
+
+		// Sum_i = Sum_{i-1} xor ENCIPHER(K, A_i xor Offset_i)
+		xorBytes(buf[:sz], offsets[:sz], additionalData)
+		ecbEncryptBlocks(o.ecb, buf[:sz])
+		for i := 0; i < sz; i += blockSize {
+			xorBytes(sum[:], sum[:], buf[i:i+blockSize])
+		}
+
+		additionalData = additionalData[sz:]
+	}
+	if r := len(additionalData); r > 0 {
+		var tmp [blockSize]byte
+
+		// Offset_* = Offset_m xor L_*
+		// CipherInput = (A_* || 1 || zeros(127-bitlen(A_*))) xor Offset_*
+		// Sum = Sum_m xor ENCIPHER(K, CipherInput)
+		xorBytes(offset[:], offset[:], o.lStar[:])
+		copy(tmp[:], additionalData)
+		tmp[r] = 0x80
+		xorBytes(tmp[:], tmp[:], offset[:])
+		o.ecb.Encrypt(tmp[:], tmp[:])
+		xorBytes(sum[:], sum[:], tmp[:])
+		memwipe(tmp[:])
+	}
+
+	memwipe(offset[:])
+	memwipe(buf)
+	memwipe(offsets)
+}
+
+func (o *ocbImpl) crypt(dst, src []byte, offset, checksum *[blockSize]byte, decrypt bool) {
+	cryptFn := ecbEncryptBlocks
+	if decrypt {
+		cryptFn = ecbDecryptBlocks
+	}
+	buf := make([]byte, o.stride*blockSize)
+	offsets := make([]byte, o.stride*blockSize)
+
+	idx := uint64(0)
+	for len(src) >= blockSize {
+		n := len(src) / blockSize
+		if n > o.stride {
+			n = o.stride
+		}
+		sz := n * blockSize
+
+		// Offset_i = Offset_{i-1} xor L_{ntz(i)}
+		for i := 0; i < n; i++ {
+			idx++
+			xorBytes(offset[:], offset[:], o.l[bits.TrailingZeros64(idx)][:])
+			copy(offsets[i*blockSize:], offset[:])
+		}
+
+		// C_i = Offset_i xor ENCIPHER(K, P_i xor Offset_i)
+		// P_i = Offset_i xor DECIPHER(K, C_i xor Offset_i)
+		// Checksum_i = Checksum_{i-1} xor P_i
+		if !decrypt {
+			for i := 0; i < sz; i += blockSize {
+				xorBytes(checksum[:], checksum[:], src[i:i+blockSize])
+			}
+		}
+		xorBytes(buf[:sz], offsets[:sz], src)
+		cryptFn(o.ecb, buf[:sz])
+		xorBytes(dst, offsets[:sz], buf[:sz])
+		if decrypt {
+			for i := 0; i < sz; i += blockSize {
+				xorBytes(checksum[:], checksum[:], dst[i:i+blockSize])
+			}
+		}
+
+		dst, src = dst[sz:], src[sz:]
+	}
+	if r := len(src); r > 0 {
+		var pad, tmp [blockSize]byte
+
+		// Offset_* = Offset_m xor L_*
+		// Pad = ENCIPHER(K, Offset_*)
+		// C_* = P_* xor Pad[1..bitlen(P_*)]
+		// Checksum_* = Checksum_m xor (P_* || 1 || zeros(127-bitlen(P_*)))
+		xorBytes(offset[:], offset[:], o.lStar[:])
+		o.ecb.Encrypt(pad[:], offset[:])
+		copy(tmp[:], src)
+		xorBytes(dst, src, pad[:])
+		if decrypt {
+			copy(tmp[:], dst[:r])
+		}
+		tmp[r] = 0x80
+		xorBytes(checksum[:], checksum[:], tmp[:])
+		memwipe(pad[:])
+		memwipe(tmp[:])
+	}
+
+	memwipe(buf)
+	memwipe(offsets)
+}
+
+func (o *ocbImpl) tag(tag *[blockSize]byte, offset, checksum *[blockSize]byte, additionalData []byte) {
+	// Tag = ENCIPHER(K, Checksum xor Offset xor L_$) xor HASH(K,A)
+	xorBytes(tag[:], checksum[:], offset[:])
+	xorBytes(tag[:], tag[:], o.lDollar[:])
+	o.ecb.Encrypt(tag[:], tag[:])
+
+	var sum [blockSize]byte
+	o.hash(&sum, additionalData)
+	xorBytes(tag[:], tag[:], sum[:])
+}
+
+func (o *ocbImpl) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != o.nonceSize {
+		panic("bsaes/ocbImpl.Seal: nonce with invalid size provided")
+	}
+
+	sz := len(plaintext)
+	out := make([]byte, sz+o.tagSize)
+
+	var offset, checksum, tag [blockSize]byte
+	o.initOffset(&offset, nonce)
+	o.crypt(out, plaintext, &offset, &checksum, false)
+	o.tag(&tag, &offset, &checksum, additionalData)
+	copy(out[sz:], tag[:])
+
+	dst = append(dst, out...)
+	return dst
+}
+
+func (o *ocbImpl) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != o.nonceSize {
+		panic("bsaes/ocbImpl.Open: nonce with invalid size provided")
+	}
+
+	sz := len(ciphertext)
+	if sz < o.tagSize {
+		return nil, errFail
+	}
+	sz -= o.tagSize
+
+	out := make([]byte, sz)
+
+	var offset, checksum, tag [blockSize]byte
+	o.initOffset(&offset, nonce)
+	o.crypt(out, ciphertext[:sz], &offset, &checksum, true)
+	o.tag(&tag, &offset, &checksum, additionalData)
+
+	if subtle.ConstantTimeCompare(tag[:o.tagSize], ciphertext[sz:]) != 1 {
+		memwipe(out)
+		return nil, errFail
+	}
+
+	dst = append(dst, out...)
+	return dst, nil
+}
+
+func newOCBImpl(ecb bulkECBAble, nonceSize, tagSize int) cipher.AEAD {
+	o := new(ocbImpl)
+	o.ecb = ecb
+	o.nonceSize = nonceSize
+	o.tagSize = tagSize
+	o.stride = ecb.Stride()
+
+	// L_* = ENCIPHER(K, zeros(128))
+	// L_$ = double(L_*)
+	// L_0 = double(L_$)
+	// L_i = double(L_{i-1}) for every integer i > 0
+	o.ecb.Encrypt(o.lStar[:], o.lStar[:])
+	o.lDollar = o.lStar
+	gfDouble(&o.lDollar)
+	o.l[0] = o.lDollar
+	gfDouble(&o.l[0])
+	for i := 1; i < ocbNumL; i++ {
+		o.l[i] = o.l[i-1]
+		gfDouble(&o.l[i])
+	}
+
+	runtime.SetFinalizer(o, (*ocbImpl).Reset)
+
+	return o
+}
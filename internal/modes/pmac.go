@@ -0,0 +1,179 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package modes
+
+import (
+	"crypto/cipher"
+	"errors"
+	"hash"
+	"math/bits"
+	"runtime"
+)
+
+const pmacNumL = 64 // Enough for any block index representable in a uint64.
+
+// NewPMAC returns a PMAC1 hash.Hash instance.
+func NewPMAC(b cipher.Block) (hash.Hash, error) {
+	if b.BlockSize() != blockSize {
+		return nil, errors.New("bsaes/NewPMAC: PMAC requires 128 bit block sizes")
+	}
+
+	return newPMACImpl(toBulkECB(b)), nil
+}
+
+// pmacImpl is a constant time PMAC1 implementation, that buffers up to
+// Stride blocks of input so that the block cipher calls can be batched.
+type pmacImpl struct {
+	ecb bulkECBAble
+
+	lInv [blockSize]byte
+	l    [pmacNumL][blockSize]byte
+
+	sum    [blockSize]byte
+	offset [blockSize]byte
+	ctr    uint64
+
+	buf []byte
+	tmp []byte
+	n   int
+}
+
+func (p *pmacImpl) Size() int {
+	return blockSize
+}
+
+func (p *pmacImpl) BlockSize() int {
+	return blockSize
+}
+
+func (p *pmacImpl) Reset() {
+	memwipe(p.sum[:])
+	memwipe(p.offset[:])
+	memwipe(p.buf)
+	memwipe(p.tmp)
+	p.ctr = 0
+	p.n = 0
+}
+
+func (p *pmacImpl) Write(b []byte) (int, error) {
+	bLen := len(b)
+	for len(b) > 0 {
+		// The final block is treated differently, so a full buffer is only
+		// processed once it is known that more data follows.
+		if p.n == len(p.buf) {
+			p.ctr = p.processBlocks(&p.sum, &p.offset, p.ctr, p.buf)
+			p.n = 0
+		}
+		n := copy(p.buf[p.n:], b)
+		p.n += n
+		b = b[n:]
+	}
+	return bLen, nil
+}
+
+func (p *pmacImpl) Sum(b []byte) []byte {
+	// Process all but the final block, without altering the state.
+	sum, offset := p.sum, p.offset
+	var lastIdx int
+	if p.n > 0 {
+		lastIdx = ((p.n - 1) / blockSize) * blockSize
+	}
+	p.processBlocks(&sum, &offset, p.ctr, p.buf[:lastIdx])
+
+	// If the final block is complete, Sigma = Sigma xor M[m] xor L(-1),
+	// otherwise Sigma = Sigma xor pad(M[m]).
+	last := p.buf[lastIdx:p.n]
+	xorBytes(sum[:], last, sum[:])
+	if len(last) == blockSize {
+		xorBytes(sum[:], sum[:], p.lInv[:])
+	} else {
+		sum[len(last)] ^= 0x80
+	}
+
+	p.ecb.Encrypt(sum[:], sum[:])
+	b = append(b, sum[:]...)
+	memwipe(sum[:])
+	memwipe(offset[:])
+
+	return b
+}
+
+func (p *pmacImpl) processBlocks(sum, offset *[blockSize]byte, ctr uint64, blocks []byte) uint64 {
+	sz := len(blocks)
+	if sz == 0 {
+		return ctr
+	}
+
+	// Offset = Offset xor L(ntz(i)), Sigma = Sigma xor E_K(M[i] xor Offset)
+	tmp := p.tmp[:sz]
+	for i := 0; i < sz; i += blockSize {
+		ctr++
+		xorBytes(offset[:], offset[:], p.l[bits.TrailingZeros64(ctr)][:])
+		xorBytes(tmp[i:i+blockSize], blocks[i:i+blockSize], offset[:])
+	}
+	ecbEncryptBlocks(p.ecb, tmp)
+	for i := 0; i < sz; i += blockSize {
+		xorBytes(sum[:], sum[:], tmp[i:i+blockSize])
+	}
+	memwipe(tmp)
+
+	return ctr
+}
+
+func (p *pmacImpl) wipe() {
+	p.Reset()
+	memwipe(p.lInv[:])
+	for i := range p.l {
+		memwipe(p.l[i][:])
+	}
+}
+
+func newPMACImpl(ecb bulkECBAble) *pmacImpl {
+	p := new(pmacImpl)
+	p.ecb = ecb
+	p.buf = make([]byte, ecb.Stride()*blockSize)
+	p.tmp = make([]byte, ecb.Stride()*blockSize)
+
+	// L(0) = E_K(0^n), L(i) = L(i-1) * x
+	var l [blockSize]byte
+	p.ecb.Encrypt(l[:], l[:])
+	for i := range p.l {
+		p.l[i] = l
+		gfDouble(&l)
+	}
+
+	// L(-1) = L * x^-1
+	l = p.l[0]
+	lsb := l[blockSize-1] & 1
+	for i := blockSize - 1; i > 0; i-- {
+		l[i] = (l[i] >> 1) | (l[i-1] << 7)
+	}
+	l[0] = (l[0] >> 1) ^ (0x80 & -lsb)
+	l[blockSize-1] ^= 0x43 & -lsb
+	p.lInv = l
+	memwipe(l[:])
+
+	runtime.SetFinalizer(p, (*pmacImpl).wipe)
+
+	return p
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package modes
+
+import (
+	"crypto/cipher"
+	"errors"
+	"hash"
+	"runtime"
+
+	"git.schwanenlied.me/yawning/bsaes.git/poly1305"
+)
+
+// NewPoly1305AES returns a Poly1305-AES hash.Hash instance, with the AES
+// instance b keyed with k, the 16 byte r, and the 16 byte nonce.
+func NewPoly1305AES(b cipher.Block, r, nonce []byte) (hash.Hash, error) {
+	if b.BlockSize() != blockSize {
+		return nil, errors.New("bsaes/NewPoly1305AES: Poly1305-AES requires 128 bit block sizes")
+	}
+	if len(r) != blockSize {
+		return nil, errors.New("bsaes/NewPoly1305AES: invalid r size")
+	}
+	if len(nonce) != blockSize {
+		return nil, errors.New("bsaes/NewPoly1305AES: invalid nonce size")
+	}
+
+	// Poly1305-AES_(k,r)(n, m) = Poly1305_(r, AES_k(n))(m)
+	var key [poly1305.KeySize]byte
+	copy(key[:blockSize], r)
+	b.Encrypt(key[blockSize:], nonce)
+
+	p := &poly1305AESImpl{poly1305.New(&key)}
+	memwipe(key[:])
+
+	runtime.SetFinalizer(p, (*poly1305AESImpl).wipe)
+
+	return p, nil
+}
+
+type poly1305AESImpl struct {
+	*poly1305.MAC
+}
+
+func (p *poly1305AESImpl) wipe() {
+	p.Clear()
+}
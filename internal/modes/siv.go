@@ -0,0 +1,206 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package modes
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"errors"
+)
+
+const sivMaxComponents = 126 // S2V supports 127 components, including P.
+
+// SIV is an AES-SIV (RFC 5297) instance.
+type SIV struct {
+	mac *cmacImpl
+	ctr bulkECBAble
+}
+
+// NewSIV returns an AES-SIV instance, with k1 used for S2V and k2 used for
+// CTR mode.
+func NewSIV(k1, k2 cipher.Block) (*SIV, error) {
+	if k1.BlockSize() != blockSize || k2.BlockSize() != blockSize {
+		return nil, errors.New("bsaes/NewSIV: SIV requires 128 bit block sizes")
+	}
+
+	s := new(SIV)
+	s.mac = newCMACImpl(toBulkECB(k1))
+	s.ctr = toBulkECB(k2)
+	return s, nil
+}
+
+// Overhead returns the difference between the lengths of a plaintext and
+// its ciphertext.
+func (s *SIV) Overhead() int {
+	return blockSize
+}
+
+// Seal encrypts and authenticates plaintext, authenticates the vector of
+// additional data, and appends the result to dst, returning the updated
+// slice.
+func (s *SIV) Seal(dst, plaintext []byte, additionalData ...[]byte) []byte {
+	if len(additionalData) > sivMaxComponents {
+		panic("bsaes/SIV.Seal: too many additional data components")
+	}
+
+	sz := len(plaintext)
+	out := make([]byte, blockSize+sz)
+
+	// V = S2V(K1, AD1, ..., ADn, P), C = CTR(K2, Q, P)
+	var v [blockSize]byte
+	s.s2v(&v, plaintext, additionalData)
+	copy(out, v[:])
+	s.xorKeyStream(out[blockSize:], plaintext, &v)
+
+	dst = append(dst, out...)
+	return dst
+}
+
+// Open decrypts and authenticates ciphertext, authenticates the vector of
+// additional data, and if successful appends the resulting plaintext to
+// dst, returning the updated slice.
+func (s *SIV) Open(dst, ciphertext []byte, additionalData ...[]byte) ([]byte, error) {
+	if len(additionalData) > sivMaxComponents {
+		return nil, errFail
+	}
+
+	sz := len(ciphertext)
+	if sz < blockSize {
+		return nil, errFail
+	}
+	sz -= blockSize
+
+	var iv, v [blockSize]byte
+	copy(iv[:], ciphertext)
+	out := make([]byte, sz)
+	s.xorKeyStream(out, ciphertext[blockSize:], &iv)
+	s.s2v(&v, out, additionalData)
+
+	if subtle.ConstantTimeCompare(iv[:], v[:]) != 1 {
+		memwipe(out)
+		return nil, errFail
+	}
+
+	dst = append(dst, out...)
+	return dst, nil
+}
+
+func (s *SIV) s2v(v *[blockSize]byte, plaintext []byte, additionalData [][]byte) {
+	var d, tmp [blockSize]byte
+
+	// D = AES-CMAC(K, <zero>)
+	m := *s.mac
+	m.Write(d[:])
+	m.sum(&d)
+
+	// D = dbl(D) xor AES-CMAC(K, Si)
+	for _, ad := range additionalData {
+		m.Reset()
+		m.Write(ad)
+		m.sum(&tmp)
+		gfDouble(&d)
+		xorBytes(d[:], d[:], tmp[:])
+	}
+
+	m.Reset()
+	if pLen := len(plaintext); pLen >= blockSize {
+		// T = Sn xorend D
+		m.Write(plaintext[:pLen-blockSize])
+		xorBytes(tmp[:], plaintext[pLen-blockSize:], d[:])
+	} else {
+		// T = dbl(D) xor pad(Sn)
+		gfDouble(&d)
+		memwipe(tmp[:])
+		copy(tmp[:], plaintext)
+		tmp[pLen] = 0x80
+		xorBytes(tmp[:], tmp[:], d[:])
+	}
+	m.Write(tmp[:])
+
+	// V = AES-CMAC(K, T)
+	m.sum(v)
+
+	m.wipe()
+	memwipe(d[:])
+	memwipe(tmp[:])
+}
+
+func (s *SIV) xorKeyStream(dst, src []byte, v *[blockSize]byte) {
+	// Q = V bitand (1^64 || 0^1 || 1^31 || 0^1 || 1^31)
+	q := *v
+	q[8] &= 0x7f
+	q[12] &= 0x7f
+
+	ctr := newCTRImpl(s.ctr, q[:])
+	ctr.XORKeyStream(dst, src)
+	ctr.(*ctrImpl).Reset()
+}
+
+// NewSIVAEAD returns an AES-SIV instance as a cipher.AEAD, with k1 used for
+// S2V and k2 used for CTR mode.  If nonceSize is non-zero, the nonce is
+// passed to S2V as the final additional data component (RFC 5297 Section 3),
+// otherwise SIV is used as a deterministic AEAD.
+func NewSIVAEAD(k1, k2 cipher.Block, nonceSize int) (cipher.AEAD, error) {
+	if nonceSize < 0 {
+		return nil, errors.New("bsaes/NewSIVAEAD: invalid nonce size")
+	}
+	s, err := NewSIV(k1, k2)
+	if err != nil {
+		return nil, err
+	}
+	return &sivAEAD{s, nonceSize}, nil
+}
+
+type sivAEAD struct {
+	siv       *SIV
+	nonceSize int
+}
+
+func (a *sivAEAD) NonceSize() int {
+	return a.nonceSize
+}
+
+func (a *sivAEAD) Overhead() int {
+	return blockSize
+}
+
+func (a *sivAEAD) components(nonce, additionalData []byte) [][]byte {
+	if a.nonceSize == 0 {
+		return [][]byte{additionalData}
+	}
+	return [][]byte{additionalData, nonce}
+}
+
+func (a *sivAEAD) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != a.nonceSize {
+		panic("bsaes/sivAEAD.Seal: nonce with invalid size provided")
+	}
+	return a.siv.Seal(dst, plaintext, a.components(nonce, additionalData)...)
+}
+
+func (a *sivAEAD) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != a.nonceSize {
+		panic("bsaes/sivAEAD.Open: nonce with invalid size provided")
+	}
+	return a.siv.Open(dst, ciphertext, a.components(nonce, additionalData)...)
+}
@@ -0,0 +1,436 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package modes
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"math/bits"
+	"runtime"
+)
+
+const (
+	umacL1ChunkSize = 1024
+	umacNHBlockSize = 32
+	umacMaxIters    = 4
+
+	umacL2Poly64Words = 1 << 14 // 2^17 bytes of L1-HASH output.
+	umacP36           = 1<<36 - 5
+	umacP64           = 1<<64 - 59
+	umacP128Offset    = 159
+)
+
+// UMAC is an UMAC (RFC 4418) instance.
+type UMAC struct {
+	pad bulkECBAble
+
+	nhKey  [umacL1ChunkSize/4 + 4*(umacMaxIters-1)]uint32
+	l2Key  [umacMaxIters]umacL2Key
+	l3Key1 [umacMaxIters][8]uint64
+	l3Key2 [umacMaxIters]uint32
+
+	iters    int
+	tagSize  int
+	nonce    [blockSize]byte
+	nonceLen int
+
+	buf   [umacL1ChunkSize]byte
+	n     int
+	total uint64
+	l2    [umacMaxIters]umacL2State
+}
+
+type umacL2Key struct {
+	k64          uint64
+	k128Hi, k128 uint64
+}
+
+type umacL2State struct {
+	y64          uint64
+	y128Hi, y128 uint64
+	pending      uint64
+	words        int
+}
+
+// NewUMAC returns an UMAC instance with a tagSize byte tag (4, 8, 12, or 16
+// bytes), with the 128 bit key, using ctor to instantiate the AES-128
+// instances.
+func NewUMAC(ctor func([]byte) cipher.Block, key []byte, tagSize int) (*UMAC, error) {
+	if len(key) != blockSize {
+		return nil, errors.New("bsaes/NewUMAC: invalid key size")
+	}
+	switch tagSize {
+	case 4, 8, 12, 16:
+	default:
+		return nil, errors.New("bsaes/NewUMAC: invalid tag size")
+	}
+
+	u := new(UMAC)
+	u.iters = tagSize / 4
+	u.tagSize = tagSize
+
+	// The KDF output for every subkey is generated with a single batch
+	// of block cipher calls, each block being
+	// AES(K, uint2str(index, 8) || uint2str(i, 8)).
+	sizes := [...]int{
+		blockSize,                        // Pad key
+		umacL1ChunkSize + (u.iters-1)*16, // L1Key
+		u.iters * 24,                     // L2Key
+		u.iters * 64,                     // L3Key1
+		u.iters * 4,                      // L3Key2
+	}
+	var offsets [len(sizes) + 1]int
+	for i, sz := range sizes {
+		offsets[i+1] = offsets[i] + (sz+blockSize-1)&^(blockSize-1)
+	}
+	buf := make([]byte, offsets[len(sizes)])
+	for index := range sizes {
+		for i, off := 1, offsets[index]; off < offsets[index+1]; i, off = i+1, off+blockSize {
+			binary.BigEndian.PutUint64(buf[off:], uint64(index))
+			binary.BigEndian.PutUint64(buf[off+8:], uint64(i))
+		}
+	}
+	b := toBulkECB(ctor(key))
+	ecbEncryptBlocks(b, buf)
+	b.Reset()
+
+	u.pad = toBulkECB(ctor(buf[:blockSize]))
+
+	l1Key := buf[offsets[1]:]
+	for i := 0; i < sizes[1]/4; i++ {
+		u.nhKey[i] = binary.BigEndian.Uint32(l1Key[i*4:])
+	}
+
+	l2Key, l3Key1, l3Key2 := buf[offsets[2]:], buf[offsets[3]:], buf[offsets[4]:]
+	for i := 0; i < u.iters; i++ {
+		k := &u.l2Key[i]
+		k.k64 = binary.BigEndian.Uint64(l2Key[i*24:]) & 0x01ffffff01ffffff
+		k.k128Hi = binary.BigEndian.Uint64(l2Key[i*24+8:]) & 0x01ffffff01ffffff
+		k.k128 = binary.BigEndian.Uint64(l2Key[i*24+16:]) & 0x01ffffff01ffffff
+
+		for j := 0; j < 8; j++ {
+			u.l3Key1[i][j] = umacModP36(binary.BigEndian.Uint64(l3Key1[i*64+j*8:]))
+		}
+		u.l3Key2[i] = binary.BigEndian.Uint32(l3Key2[i*4:])
+	}
+	memwipe(buf)
+
+	u.Reset()
+
+	runtime.SetFinalizer(u, (*UMAC).wipe)
+
+	return u, nil
+}
+
+// Size returns the tag size in bytes.
+func (u *UMAC) Size() int {
+	return u.tagSize
+}
+
+// BlockSize returns the NH block size in bytes.
+func (u *UMAC) BlockSize() int {
+	return umacNHBlockSize
+}
+
+// SetNonce sets the nonce (1 to 16 bytes) used to generate the pad for the
+// tag returned by Sum.  The nonce must be unique per tag.
+func (u *UMAC) SetNonce(nonce []byte) {
+	if len(nonce) == 0 || len(nonce) > blockSize {
+		panic("bsaes/UMAC: nonce with invalid size provided")
+	}
+	u.nonce = [blockSize]byte{}
+	copy(u.nonce[:], nonce)
+	u.nonceLen = len(nonce)
+}
+
+// Reset discards the data that has been written so far, while keeping the
+// key and the nonce.
+func (u *UMAC) Reset() {
+	memwipe(u.buf[:])
+	u.n = 0
+	u.total = 0
+	for i := range u.l2 {
+		u.l2[i] = umacL2State{y64: 1, y128: 1}
+	}
+}
+
+// Write adds more data to the running tag.  It never returns an error.
+func (u *UMAC) Write(p []byte) (int, error) {
+	pLen := len(p)
+	u.total += uint64(pLen)
+	for len(p) > 0 {
+		// The final chunk is treated differently, so a full buffer is only
+		// processed once it is known that more data follows.
+		if u.n == umacL1ChunkSize {
+			var nh [umacMaxIters]uint64
+			u.nh(&nh, u.buf[:])
+			for i := 0; i < u.iters; i++ {
+				u.l2[i].update(&u.l2Key[i], nh[i]+umacL1ChunkSize*8)
+			}
+			u.n = 0
+		}
+		n := copy(u.buf[u.n:], p)
+		u.n += n
+		p = p[n:]
+	}
+	return pLen, nil
+}
+
+// Sum appends the current tag to b and returns the resulting slice.  It
+// does not change the underlying state.
+func (u *UMAC) Sum(b []byte) []byte {
+	if u.nonceLen == 0 {
+		panic("bsaes/UMAC: nonce not set")
+	}
+
+	var tag [blockSize]byte
+	tmp := *u
+	tmp.uhash(&tag)
+	tmp.wipeState()
+	u.pdf(&tag)
+
+	b = append(b, tag[:u.tagSize]...)
+	memwipe(tag[:])
+	return b
+}
+
+func (u *UMAC) uhash(out *[blockSize]byte) {
+	// L1-HASH of the final chunk, zero padded to a positive multiple of
+	// 32 bytes, with the length in bits of the unpadded chunk.
+	var nh [umacMaxIters]uint64
+	sz := (u.n + umacNHBlockSize - 1) &^ (umacNHBlockSize - 1)
+	if sz == 0 {
+		sz = umacNHBlockSize
+	}
+	memwipe(u.buf[u.n:sz])
+	u.nh(&nh, u.buf[:sz])
+
+	for i := 0; i < u.iters; i++ {
+		a := nh[i] + uint64(u.n)*8
+
+		// B = zeroes(8) || A for single chunk messages, L2-HASH(A)
+		// otherwise.
+		var bHi, bLo uint64
+		if u.total <= umacL1ChunkSize {
+			bLo = a
+		} else {
+			u.l2[i].update(&u.l2Key[i], a)
+			bHi, bLo = u.l2[i].finish(&u.l2Key[i])
+		}
+
+		// C = L3-HASH(L3Key1, L3Key2, B)
+		var y uint64
+		for j := 0; j < 4; j++ {
+			y += ((bHi >> (48 - 16*uint(j))) & 0xffff) * u.l3Key1[i][j]
+			y += ((bLo >> (48 - 16*uint(j))) & 0xffff) * u.l3Key1[i][4+j]
+		}
+		c := uint32(umacModP36(y)) ^ u.l3Key2[i]
+		binary.BigEndian.PutUint32(out[i*4:], c)
+	}
+}
+
+func (u *UMAC) pdf(tag *[blockSize]byte) {
+	// For 32 and 64 bit tags, the low bits of the nonce select which part
+	// of the AES output is used as the pad.
+	var nonce, pad [blockSize]byte
+	nonce = u.nonce
+	index := 0
+	if u.tagSize <= 8 {
+		index = int(nonce[u.nonceLen-1]) & (blockSize/u.tagSize - 1)
+		nonce[u.nonceLen-1] ^= byte(index)
+	}
+	u.pad.Encrypt(pad[:], nonce[:])
+	xorBytes(tag[:u.tagSize], tag[:u.tagSize], pad[index*u.tagSize:])
+	memwipe(pad[:])
+}
+
+// nh adds NH(L1Key_i, M) for each iteration to out, with the chunk being
+// a multiple of 32 bytes.
+func (u *UMAC) nh(out *[umacMaxIters]uint64, chunk []byte) {
+	for off := 0; off < len(chunk); off += umacNHBlockSize {
+		var m [8]uint32
+		for j := range m {
+			m[j] = binary.LittleEndian.Uint32(chunk[off+j*4:])
+		}
+		for i := 0; i < u.iters; i++ {
+			k := u.nhKey[off/4+i*4:]
+			out[i] += uint64(m[0]+k[0])*uint64(m[4]+k[4]) +
+				uint64(m[1]+k[1])*uint64(m[5]+k[5]) +
+				uint64(m[2]+k[2])*uint64(m[6]+k[6]) +
+				uint64(m[3]+k[3])*uint64(m[7]+k[7])
+		}
+	}
+}
+
+func (u *UMAC) wipeState() {
+	u.Reset()
+	for i := range u.l2 {
+		u.l2[i] = umacL2State{}
+	}
+}
+
+func (u *UMAC) wipe() {
+	u.wipeState()
+	for i := range u.nhKey {
+		u.nhKey[i] = 0
+	}
+	for i := range u.l2Key {
+		u.l2Key[i] = umacL2Key{}
+		u.l3Key1[i] = [8]uint64{}
+		u.l3Key2[i] = 0
+	}
+	u.pad.Reset()
+}
+
+// update absorbs the L1-HASH output a.  The first 2^17 bytes are hashed with
+// POLY64, and the rest with POLY128, prefixed with the POLY64 output.
+func (s *umacL2State) update(k *umacL2Key, a uint64) {
+	switch {
+	case s.words < umacL2Poly64Words:
+		s.y64 = umacPoly64(s.y64, k.k64, a)
+	case s.words == umacL2Poly64Words:
+		s.y128Hi, s.y128 = umacPoly128(s.y128Hi, s.y128, k.k128Hi, k.k128, 0, s.y64)
+		s.pending = a
+	case (s.words-umacL2Poly64Words)&1 == 0:
+		s.pending = a
+	default:
+		s.y128Hi, s.y128 = umacPoly128(s.y128Hi, s.y128, k.k128Hi, k.k128, s.pending, a)
+	}
+	s.words++
+}
+
+func (s *umacL2State) finish(k *umacL2Key) (uint64, uint64) {
+	if s.words <= umacL2Poly64Words {
+		return 0, s.y64
+	}
+
+	// M_2 = zeropad(M_2 || 0x80, 16)
+	if (s.words-umacL2Poly64Words)&1 == 1 {
+		s.y128Hi, s.y128 = umacPoly128(s.y128Hi, s.y128, k.k128Hi, k.k128, s.pending, 0x80<<56)
+	} else {
+		s.y128Hi, s.y128 = umacPoly128(s.y128Hi, s.y128, k.k128Hi, k.k128, 0x80<<56, 0)
+	}
+	return s.y128Hi, s.y128
+}
+
+// umacPoly64 returns one step of POLY(64, 2^64 - 2^32, k, M) over the word
+// m, in constant time.
+func umacPoly64(y, k, m uint64) uint64 {
+	// Words that are >= 2^64 - 2^32 are hashed as a marker followed by
+	// the word minus the offset.  Both paths are always computed.
+	yy := umacMulAddP64(y, k, umacP64-1)
+	yy = umacMulAddP64(yy, k, m-(1<<64-umacP64))
+	y = umacMulAddP64(y, k, m)
+
+	mask := -(((m >> 32) + 1) >> 32) // All ones iff m >= 2^64 - 2^32.
+	return (yy & mask) | (y &^ mask)
+}
+
+// umacMulAddP64 returns (k * y + m) mod 2^64 - 59, for k < 2^57.
+func umacMulAddP64(y, k, m uint64) uint64 {
+	hi, lo := bits.Mul64(k, y)
+	var c uint64
+	lo, c = bits.Add64(lo, m, 0)
+	hi += c
+
+	// 2^64 = 59 mod p
+	lo, c = bits.Add64(lo, hi*59, 0)
+	lo += c * 59
+
+	return umacCondSubP64(lo)
+}
+
+func umacCondSubP64(x uint64) uint64 {
+	t, c := bits.Add64(x, 1<<64-umacP64, 0)
+	mask := -c
+	return (t & mask) | (x &^ mask)
+}
+
+// umacPoly128 returns one step of POLY(128, 2^128 - 2^96, k, M) over the
+// word m, in constant time.
+func umacPoly128(yHi, y, kHi, k, mHi, m uint64) (uint64, uint64) {
+	// As with POLY64, both the marker and the regular path are computed.
+	yyHi, yy := umacMulAddP128(yHi, y, kHi, k, 0xffffffffffffffff, 0xffffffffffffffff-umacP128Offset)
+	mLo, b := bits.Sub64(m, umacP128Offset, 0)
+	yyHi, yy = umacMulAddP128(yyHi, yy, kHi, k, mHi-b, mLo)
+	yHi, y = umacMulAddP128(yHi, y, kHi, k, mHi, m)
+
+	mask := -(((mHi >> 32) + 1) >> 32)
+	return (yyHi & mask) | (yHi &^ mask), (yy & mask) | (y &^ mask)
+}
+
+// umacMulAddP128 returns (k * y + m) mod 2^128 - 159, for k < 2^121.
+func umacMulAddP128(yHi, y, kHi, k, mHi, m uint64) (uint64, uint64) {
+	// z = k * y
+	var z0, z1, z2, z3, c uint64
+	h, l := bits.Mul64(k, y)
+	z0 = l
+	z1 = h
+	h, l = bits.Mul64(kHi, y)
+	z1, c = bits.Add64(z1, l, 0)
+	z2, _ = bits.Add64(h, 0, c)
+	h, l = bits.Mul64(k, yHi)
+	z1, c = bits.Add64(z1, l, 0)
+	z2, c = bits.Add64(z2, h, c)
+	z3 = c
+	h, l = bits.Mul64(kHi, yHi)
+	z2, c = bits.Add64(z2, l, 0)
+	z3 += h + c
+
+	// z += m
+	z0, c = bits.Add64(z0, m, 0)
+	z1, c = bits.Add64(z1, mHi, c)
+	z2, c = bits.Add64(z2, 0, c)
+	z3 += c
+
+	// 2^128 = 159 mod p
+	h0, l0 := bits.Mul64(z2, umacP128Offset)
+	h1, l1 := bits.Mul64(z3, umacP128Offset)
+	mid, c := bits.Add64(l1, h0, 0)
+	top := h1 + c
+	z0, c = bits.Add64(z0, l0, 0)
+	z1, c = bits.Add64(z1, mid, c)
+	top += c
+
+	z0, c = bits.Add64(z0, top*umacP128Offset, 0)
+	z1, c = bits.Add64(z1, 0, c)
+	z0, c = bits.Add64(z0, c*umacP128Offset, 0)
+	z1 += c
+
+	// Conditionally subtract p.
+	t0, c := bits.Add64(z0, umacP128Offset, 0)
+	t1, c := bits.Add64(z1, 0, c)
+	mask := -c
+	return (t1 & mask) | (z1 &^ mask), (t0 & mask) | (z0 &^ mask)
+}
+
+// umacModP36 returns x mod 2^36 - 5, in constant time.
+func umacModP36(x uint64) uint64 {
+	// 2^36 = 5 mod p
+	x = (x & (1<<36 - 1)) + (x>>36)*5
+	x = (x & (1<<36 - 1)) + (x>>36)*5
+	t := x - umacP36
+	mask := -(t >> 63) // All ones iff x < p.
+	return (x & mask) | (t &^ mask)
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package modes
+
+import (
+	"crypto/cipher"
+	"errors"
+	"runtime"
+)
+
+const (
+	xaesNonceSize = 192 / 8
+	xaesKeySize   = 32
+)
+
+// NewXAES256GCM returns a XAES-256-GCM (C2SP) cipher.AEAD instance, with the
+// AES-256 instance b.
+func NewXAES256GCM(b cipher.Block) (cipher.AEAD, error) {
+	if b.BlockSize() != blockSize {
+		return nil, errors.New("bsaes/NewXAES256GCM: XAES-256-GCM requires 128 bit block sizes")
+	}
+
+	x := new(xaesImpl)
+	x.b = b
+	x.ecb = toBulkECB(b)
+
+	// L = AES-256ₖ(0¹²⁸), K1 = L << 1 (with the conditional xor)
+	x.ecb.Encrypt(x.k1[:], x.k1[:])
+	gfDouble(&x.k1)
+
+	runtime.SetFinalizer(x, (*xaesImpl).Reset)
+
+	return x, nil
+}
+
+type xaesImpl struct {
+	b   cipher.Block
+	ecb bulkECBAble
+	k1  [blockSize]byte
+}
+
+func (x *xaesImpl) NonceSize() int {
+	return xaesNonceSize
+}
+
+func (x *xaesImpl) Overhead() int {
+	return gcmTagSize
+}
+
+func (x *xaesImpl) Reset() {
+	memwipe(x.k1[:])
+}
+
+func (x *xaesImpl) deriveKey(nonce []byte) (cipher.AEAD, bulkECBAble) {
+	// M1 = 0x00 || 0x01 || X || 0x00 || N[:12]
+	// M2 = 0x00 || 0x02 || X || 0x00 || N[:12]
+	// Kₓ = AES-256ₖ(M1 ⊕ K1) || AES-256ₖ(M2 ⊕ K1)
+	var m [2 * blockSize]byte
+	for i := 0; i < 2; i++ {
+		blk := m[i*blockSize : (i+1)*blockSize]
+		blk[1] = byte(i + 1)
+		blk[2] = 'X'
+		copy(blk[4:], nonce[:12])
+		xorBytes(blk, blk, x.k1[:])
+	}
+	ecbEncryptBlocks(x.ecb, m[:])
+
+	ecb := newCipher(x.b, m[:xaesKeySize])
+	memwipe(m[:])
+
+	return newGCMImpl(ecb, gcmNonceSize), ecb
+}
+
+func (x *xaesImpl) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != xaesNonceSize {
+		panic("bsaes/xaesImpl.Seal: nonce with invalid size provided")
+	}
+
+	g, ecb := x.deriveKey(nonce)
+	defer ecb.Reset()
+
+	return g.Seal(dst, nonce[12:], plaintext, additionalData)
+}
+
+func (x *xaesImpl) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != xaesNonceSize {
+		panic("bsaes/xaesImpl.Open: nonce with invalid size provided")
+	}
+
+	g, ecb := x.deriveKey(nonce)
+	defer ecb.Reset()
+
+	return g.Open(dst, nonce[12:], ciphertext, additionalData)
+}
@@ -0,0 +1,166 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package modes
+
+import (
+	"crypto/cipher"
+	"errors"
+	"hash"
+	"runtime"
+)
+
+const xcbcMAC96Size = 96 / 8
+
+// NewXCBCMAC96 returns an AES-XCBC-MAC-96 (RFC 3566) hash.Hash instance,
+// with the 128 bit key, using ctor to instantiate the AES-128 instance.
+func NewXCBCMAC96(ctor func([]byte) cipher.Block, key []byte) (hash.Hash, error) {
+	if len(key) != blockSize {
+		return nil, errors.New("bsaes/NewXCBCMAC96: invalid key size")
+	}
+
+	return newXCBCImpl(ctor(key), xcbcMAC96Size), nil
+}
+
+// NewXCBCPRF128 returns an AES-XCBC-PRF-128 (RFC 4434) hash.Hash instance,
+// with the variable length key, using ctor to instantiate the AES-128
+// instances.
+func NewXCBCPRF128(ctor func([]byte) cipher.Block, key []byte) hash.Hash {
+	var k [blockSize]byte
+
+	// If the key is exactly 128 bits, it is used as is.  Shorter keys are
+	// padded with zeros, and longer keys are replaced with
+	// AES-XCBC-PRF-128(0^128, key).
+	if len(key) <= blockSize {
+		copy(k[:], key)
+	} else {
+		b := ctor(k[:])
+		x := newXCBCImpl(b, blockSize)
+		x.Write(key)
+		x.sum(&k)
+		x.wipe()
+		toBulkECB(b).Reset()
+	}
+
+	b := ctor(k[:])
+	memwipe(k[:])
+
+	return newXCBCImpl(b, blockSize)
+}
+
+// xcbcImpl is a constant time AES-XCBC-MAC (RFC 3566) implementation.
+type xcbcImpl struct {
+	ecb bulkECBAble
+
+	k2 [blockSize]byte
+	k3 [blockSize]byte
+
+	e    [blockSize]byte
+	buf  [blockSize]byte
+	n    int
+	size int
+}
+
+func (x *xcbcImpl) Size() int {
+	return x.size
+}
+
+func (x *xcbcImpl) BlockSize() int {
+	return blockSize
+}
+
+func (x *xcbcImpl) Reset() {
+	memwipe(x.e[:])
+	memwipe(x.buf[:])
+	x.n = 0
+}
+
+func (x *xcbcImpl) Write(p []byte) (int, error) {
+	pLen := len(p)
+	for len(p) > 0 {
+		// As with CMAC, the final block is treated differently, so a full
+		// buffer is only processed once it is known that more data follows.
+		if x.n == blockSize {
+			xorBytes(x.e[:], x.e[:], x.buf[:])
+			x.ecb.Encrypt(x.e[:], x.e[:])
+			x.n = 0
+		}
+		n := copy(x.buf[x.n:], p)
+		x.n += n
+		p = p[n:]
+	}
+	return pLen, nil
+}
+
+func (x *xcbcImpl) Sum(b []byte) []byte {
+	var tmp [blockSize]byte
+	x.sum(&tmp)
+	b = append(b, tmp[:x.size]...)
+	memwipe(tmp[:])
+	return b
+}
+
+func (x *xcbcImpl) sum(out *[blockSize]byte) {
+	var last [blockSize]byte
+
+	// If the final block is complete, M[n] ^= K2, otherwise
+	// M[n] = (M[n] || 10^j) ^ K3.
+	copy(last[:], x.buf[:x.n])
+	if x.n == blockSize {
+		xorBytes(last[:], last[:], x.k2[:])
+	} else {
+		last[x.n] = 0x80
+		xorBytes(last[:], last[:], x.k3[:])
+	}
+
+	xorBytes(out[:], x.e[:], last[:])
+	x.ecb.Encrypt(out[:], out[:])
+	memwipe(last[:])
+}
+
+func (x *xcbcImpl) wipe() {
+	x.Reset()
+	memwipe(x.k2[:])
+	memwipe(x.k3[:])
+	x.ecb.Reset()
+}
+
+func newXCBCImpl(b cipher.Block, size int) *xcbcImpl {
+	// K1 = E(K, 0x01010101...), K2 = E(K, 0x02020202...),
+	// K3 = E(K, 0x03030303...)
+	var k [3 * blockSize]byte
+	for i := range k {
+		k[i] = byte(i/blockSize + 1)
+	}
+	ecbEncryptBlocks(toBulkECB(b), k[:])
+
+	x := new(xcbcImpl)
+	x.ecb = newCipher(b, k[:blockSize])
+	copy(x.k2[:], k[blockSize:])
+	copy(x.k3[:], k[2*blockSize:])
+	x.size = size
+	memwipe(k[:])
+
+	runtime.SetFinalizer(x, (*xcbcImpl).wipe)
+
+	return x
+}
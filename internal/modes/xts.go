@@ -0,0 +1,162 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package modes
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+)
+
+// XTS is a XTS-AES (IEEE 1619) instance.
+type XTS struct {
+	k1 bulkECBAble
+	k2 bulkECBAble
+
+	stride int
+}
+
+// NewXTS returns a XTS-AES instance, with k1 used to encrypt data units, and
+// k2 used to encrypt the tweak.
+func NewXTS(k1, k2 cipher.Block) (*XTS, error) {
+	if k1.BlockSize() != blockSize || k2.BlockSize() != blockSize {
+		return nil, errors.New("bsaes/NewXTS: XTS requires 128 bit block sizes")
+	}
+
+	x := new(XTS)
+	x.k1 = toBulkECB(k1)
+	x.k2 = toBulkECB(k2)
+	x.stride = x.k1.Stride()
+
+	return x, nil
+}
+
+// Encrypt encrypts the data unit src with the data unit sequence number
+// sectorNum, and places the resulting output in dst.  src must be at least
+// 16 bytes long.
+func (x *XTS) Encrypt(dst, src []byte, sectorNum uint64) {
+	x.crypt(dst, src, sectorNum, false)
+}
+
+// Decrypt decrypts the data unit src with the data unit sequence number
+// sectorNum, and places the resulting output in dst.  src must be at least
+// 16 bytes long.
+func (x *XTS) Decrypt(dst, src []byte, sectorNum uint64) {
+	x.crypt(dst, src, sectorNum, true)
+}
+
+func (x *XTS) crypt(dst, src []byte, sectorNum uint64, decrypt bool) {
+	sLen := len(src)
+	if sLen < blockSize {
+		panic("bsaes/XTS: data unit too short")
+	}
+	if len(dst) < sLen {
+		panic("bsaes/XTS: output smaller than input")
+	}
+
+	bulkFn, fn := x.k1.BulkEncrypt, x.k1.Encrypt
+	if decrypt {
+		bulkFn, fn = x.k1.BulkDecrypt, x.k1.Decrypt
+	}
+
+	// T = E_K2(i) (x) alpha^j.
+	var tweak [blockSize]byte
+	binary.LittleEndian.PutUint64(tweak[:], sectorNum)
+	x.k2.Encrypt(tweak[:], tweak[:])
+
+	n, r := sLen/blockSize, sLen%blockSize
+	if r != 0 {
+		// The last complete block participates in ciphertext stealing.
+		n--
+	}
+
+	buf := make([]byte, x.stride*blockSize)
+	tweaks := make([]byte, x.stride*blockSize)
+	for n >= x.stride { // Stride blocks at a time.
+		for i := 0; i < x.stride; i++ {
+			copy(tweaks[i*blockSize:], tweak[:])
+			mulAlpha(&tweak)
+		}
+		xorBytes(buf, tweaks, src)
+		bulkFn(buf, buf)
+		xorBytes(dst, tweaks, buf)
+
+		dst, src = dst[x.stride*blockSize:], src[x.stride*blockSize:]
+		n -= x.stride
+	}
+	for n > 0 { // Process the remainder one block at a time.
+		x.cryptBlock(fn, dst, src, &tweak)
+		mulAlpha(&tweak)
+
+		dst, src = dst[blockSize:], src[blockSize:]
+		n--
+	}
+	memwipe(buf)
+	memwipe(tweaks)
+	if r == 0 {
+		memwipe(tweak[:])
+		return
+	}
+
+	// Ciphertext stealing.  When decrypting, the final two blocks are
+	// processed with the tweaks in reverse order.
+	var cc, pp [blockSize]byte
+	tweak0, tweak1 := tweak, tweak
+	mulAlpha(&tweak1)
+	if decrypt {
+		tweak0, tweak1 = tweak1, tweak0
+	}
+
+	x.cryptBlock(fn, cc[:], src, &tweak0)
+	copy(pp[:], src[blockSize:])
+	copy(pp[r:], cc[r:])
+	copy(dst[blockSize:], cc[:r])
+	x.cryptBlock(fn, dst, pp[:], &tweak1)
+
+	memwipe(cc[:])
+	memwipe(pp[:])
+	memwipe(tweak0[:])
+	memwipe(tweak1[:])
+	memwipe(tweak[:])
+}
+
+func (x *XTS) cryptBlock(fn func(dst, src []byte), dst, src []byte, tweak *[blockSize]byte) {
+	var tmp [blockSize]byte
+
+	xorBytes(tmp[:], tweak[:], src)
+	fn(tmp[:], tmp[:])
+	xorBytes(dst, tweak[:], tmp[:])
+	memwipe(tmp[:])
+}
+
+// mulAlpha multiplies the tweak by the primitive element alpha of
+// GF(2^128), in constant time.
+func mulAlpha(tweak *[blockSize]byte) {
+	lo := binary.LittleEndian.Uint64(tweak[:])
+	hi := binary.LittleEndian.Uint64(tweak[8:])
+	carry := hi >> 63
+	hi = (hi << 1) | (lo >> 63)
+	lo = (lo << 1) ^ (0x87 & -carry)
+	binary.LittleEndian.PutUint64(tweak[:], lo)
+	binary.LittleEndian.PutUint64(tweak[8:], hi)
+}
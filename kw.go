@@ -0,0 +1,59 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package bsaes
+
+import (
+	"crypto/cipher"
+
+	"git.schwanenlied.me/yawning/bsaes.git/internal/modes"
+)
+
+// Wrap wraps the plaintext key with the key-encryption key kek, using the KW
+// algorithm (RFC 3394, SP 800-38F).  The plaintext must be a multiple of 8
+// bytes, and at least 16 bytes long.  If icv is nil, the default ICV
+// (0xA6A6A6A6A6A6A6A6) is used, otherwise it must be 8 bytes long.
+func Wrap(kek cipher.Block, icv, plaintext []byte) ([]byte, error) {
+	return modes.Wrap(kek, icv, plaintext)
+}
+
+// Unwrap unwraps the ciphertext with the key-encryption key kek, using the KW
+// algorithm (RFC 3394, SP 800-38F), and returns the plaintext key iff the
+// integrity check succeeds.  The icv is as in Wrap.
+func Unwrap(kek cipher.Block, icv, ciphertext []byte) ([]byte, error) {
+	return modes.Unwrap(kek, icv, ciphertext)
+}
+
+// WrapPad wraps the plaintext key with the key-encryption key kek, using the
+// KWP algorithm (RFC 5649, SP 800-38F), which allows for plaintexts of any
+// length between 1 and 2^32 - 1 bytes.  If icv is nil, the default ICV
+// (0xA65959A6) is used, otherwise it must be 4 bytes long.
+func WrapPad(kek cipher.Block, icv, plaintext []byte) ([]byte, error) {
+	return modes.WrapPad(kek, icv, plaintext)
+}
+
+// UnwrapPad unwraps the ciphertext with the key-encryption key kek, using the
+// KWP algorithm (RFC 5649, SP 800-38F), and returns the plaintext key iff the
+// integrity check succeeds.  The icv is as in WrapPad.
+func UnwrapPad(kek cipher.Block, icv, ciphertext []byte) ([]byte, error) {
+	return modes.UnwrapPad(kek, icv, ciphertext)
+}
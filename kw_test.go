@@ -0,0 +1,212 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package bsaes
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// The test vectors are taken from RFC 3394, Section 4, and RFC 5649,
+// Section 6.
+
+var kwVectors = []struct {
+	kek        string
+	plaintext  string
+	ciphertext string
+}{
+	// 4.1 Wrap 128 bits of Key Data with a 128-bit KEK
+	{
+		"000102030405060708090a0b0c0d0e0f",
+		"00112233445566778899aabbccddeeff",
+		"1fa68b0a8112b447aef34bd8fb5a7b829d3e862371d2cfe5",
+	},
+	// 4.2 Wrap 128 bits of Key Data with a 192-bit KEK
+	{
+		"000102030405060708090a0b0c0d0e0f1011121314151617",
+		"00112233445566778899aabbccddeeff",
+		"96778b25ae6ca435f92b5b97c050aed2468ab8a17ad84e5d",
+	},
+	// 4.3 Wrap 128 bits of Key Data with a 256-bit KEK
+	{
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+		"00112233445566778899aabbccddeeff",
+		"64e8c3f9ce0f5ba263e9777905818a2a93c8191e7d6e8ae7",
+	},
+	// 4.4 Wrap 192 bits of Key Data with a 192-bit KEK
+	{
+		"000102030405060708090a0b0c0d0e0f1011121314151617",
+		"00112233445566778899aabbccddeeff0001020304050607",
+		"031d33264e15d33268f24ec260743edce1c6c7ddee725a936ba814915c6762d2",
+	},
+	// 4.5 Wrap 192 bits of Key Data with a 256-bit KEK
+	{
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+		"00112233445566778899aabbccddeeff0001020304050607",
+		"a8f9bc1612c68b3ff6e6f4fbe30e71e4769c8b80a32cb8958cd5d17d6b254da1",
+	},
+	// 4.6 Wrap 256 bits of Key Data with a 256-bit KEK
+	{
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+		"00112233445566778899aabbccddeeff000102030405060708090a0b0c0d0e0f",
+		"28c9f404c4b810f4cbccb35cfb87f8263f5786e2d80ed326cbc7f0e71a99f43bfb988b9b7a02dd21",
+	},
+}
+
+var kwpVectors = []struct {
+	kek        string
+	plaintext  string
+	ciphertext string
+}{
+	// Wrap 20 octets with a 192-bit key
+	{
+		"5840df6e29b02af1ab493b705bf16ea1ae8338f4dcc176a8",
+		"c37b7e6492584340bed12207808941155068f738",
+		"138bdeaa9b8fa7fc61f97742e72248ee5ae6ae5360d1ae6a5f54f373fa543b6a",
+	},
+	// Wrap 7 octets with a 192-bit key
+	{
+		"5840df6e29b02af1ab493b705bf16ea1ae8338f4dcc176a8",
+		"466f7250617369",
+		"afbeb0f07dfbf5419200f2ccb50bb24f",
+	},
+}
+
+func TestKW_RFC3394(t *testing.T) {
+	for _, impl := range impls {
+		t.Logf("Testing implementation: %v\n", impl.name)
+		for i, vec := range kwVectors {
+			kek, err := hex.DecodeString(vec.kek[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			pt, err := hex.DecodeString(vec.plaintext[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			ct, err := hex.DecodeString(vec.ciphertext[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			blk := impl.ctor(kek)
+			dst, err := Wrap(blk, nil, pt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertEqual(t, i, ct, dst)
+
+			dst, err = Unwrap(blk, nil, ct)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertEqual(t, i, pt, dst)
+
+			ct[len(ct)-1] ^= 0x01
+			if _, err = Unwrap(blk, nil, ct); err == nil {
+				t.Fatalf("[%d] Unwrap succeeded with corrupted ciphertext", i)
+			}
+		}
+	}
+}
+
+func TestKWP_RFC5649(t *testing.T) {
+	for _, impl := range impls {
+		t.Logf("Testing implementation: %v\n", impl.name)
+		for i, vec := range kwpVectors {
+			kek, err := hex.DecodeString(vec.kek[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			pt, err := hex.DecodeString(vec.plaintext[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			ct, err := hex.DecodeString(vec.ciphertext[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			blk := impl.ctor(kek)
+			dst, err := WrapPad(blk, nil, pt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertEqual(t, i, ct, dst)
+
+			dst, err = UnwrapPad(blk, nil, ct)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertEqual(t, i, pt, dst)
+
+			ct[len(ct)-1] ^= 0x01
+			if _, err = UnwrapPad(blk, nil, ct); err == nil {
+				t.Fatalf("[%d] UnwrapPad succeeded with corrupted ciphertext", i)
+			}
+		}
+	}
+}
+
+func TestKW_CustomICV(t *testing.T) {
+	kek := make([]byte, 16)
+	pt := []byte("a 24 byte key to wrap!!!")
+	icv := []byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef}
+
+	for _, impl := range impls {
+		t.Logf("Testing implementation: %v\n", impl.name)
+		blk := impl.ctor(kek)
+
+		ct, err := Wrap(blk, icv, pt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if defCt, _ := Wrap(blk, nil, pt); bytes.Equal(ct, defCt) {
+			t.Fatalf("Wrap with custom ICV matches the default ICV")
+		}
+		dst, err := Unwrap(blk, icv, ct)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertEqual(t, 0, pt, dst)
+		if _, err = Unwrap(blk, nil, ct); err == nil {
+			t.Fatalf("Unwrap succeeded with the wrong ICV")
+		}
+
+		for _, sz := range []int{1, 8, 9, len(pt)} {
+			ct, err = WrapPad(blk, icv[:4], pt[:sz])
+			if err != nil {
+				t.Fatal(err)
+			}
+			dst, err = UnwrapPad(blk, icv[:4], ct)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertEqual(t, sz, pt[:sz], dst)
+			if _, err = UnwrapPad(blk, nil, ct); err == nil {
+				t.Fatalf("[%d] UnwrapPad succeeded with the wrong ICV", sz)
+			}
+		}
+	}
+}
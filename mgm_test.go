@@ -0,0 +1,138 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package bsaes
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// RFC 9058 only specifies test vectors for Kuznyechik and Magma, so these
+// were generated with an independent implementation, using the inputs from
+// RFC 9058 Appendix A.1 with AES as the underlying block cipher.
+
+const (
+	mgmTestKey   = "8899aabbccddeeff0011223344556677fedcba98765432100123456789abcdef"
+	mgmTestNonce = "1122334455667700ffeeddccbbaa9988"
+	mgmTestAD    = "0202020202020202010101010101010104040404040404040303030303030303ea0505050505050505"
+	mgmTestPT    = "1122334455667700ffeeddccbbaa998800112233445566778899aabbcceeff0a112233445566778899aabbcceeff0a002233445566778899aabbcceeff0a0011aabbcc"
+)
+
+var mgmVectors = []struct {
+	key        string
+	ad         string
+	plaintext  string
+	tagSize    int
+	ciphertext string
+}{
+	{
+		mgmTestKey,
+		mgmTestAD,
+		mgmTestPT,
+		16,
+		"089d6949e461dbfd9c2e5d99d287cef2c29a81ddeb6d3c12bd491e89e19e6675fd405838f87404d13969cea5184f8987482e7028ee28bc6af2465acc834b7df1241e879c1ecb8c65e83d6d77a71678cc923e5c",
+	},
+	{
+		mgmTestKey[:32],
+		mgmTestAD,
+		mgmTestPT,
+		16,
+		"d3dfe7f6e2fcf484ad83d20c422a04f264d5826ebef29117b506289adb2de341aaf340cd6d72040d61d3306be6d292477239a37c323e8e878857fe72d1b94d541b8bee8f40a29d21cbb052336912cdc92d5a80",
+	},
+	{
+		mgmTestKey,
+		"",
+		"",
+		16,
+		"40600e6bb7f3964f9cc53d6ee7ee5f1d",
+	},
+	{
+		mgmTestKey,
+		mgmTestAD,
+		"",
+		16,
+		"616939be6af2f7d8e80ee1e016e343ba",
+	},
+	{
+		mgmTestKey,
+		"",
+		mgmTestPT[:32],
+		16,
+		"089d6949e461dbfd9c2e5d99d287cef2d0de2056998144091d859aa22be8ea92",
+	},
+	{
+		mgmTestKey,
+		mgmTestAD,
+		mgmTestPT,
+		8,
+		"089d6949e461dbfd9c2e5d99d287cef2c29a81ddeb6d3c12bd491e89e19e6675fd405838f87404d13969cea5184f8987482e7028ee28bc6af2465acc834b7df1241e879c1ecb8c65e83d6d",
+	},
+}
+
+func TestMGM(t *testing.T) {
+	nonce, err := hex.DecodeString(mgmTestNonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, impl := range impls {
+		t.Logf("Testing implementation: %v\n", impl.name)
+		for i, vec := range mgmVectors {
+			key, err := hex.DecodeString(vec.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ad, err := hex.DecodeString(vec.ad)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pt, err := hex.DecodeString(vec.plaintext)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ct, err := hex.DecodeString(vec.ciphertext)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			m, err := NewMGM(impl.ctor(key), vec.tagSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			dst := m.Seal(nil, nonce, pt, ad)
+			assertEqual(t, i, ct, dst)
+
+			dst, err = m.Open(nil, nonce, ct, ad)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertEqual(t, i, pt, dst)
+
+			ct[0] ^= 0x80
+			if _, err = m.Open(nil, nonce, ct, ad); err == nil {
+				t.Fatalf("[%d] Open succeeded with corrupted ciphertext", i)
+			}
+		}
+	}
+}
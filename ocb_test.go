@@ -0,0 +1,277 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package bsaes
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+)
+
+// The test vectors are taken from RFC 7253, Appendix A.
+
+var ocbVectors = []struct {
+	key        string
+	tagSize    int
+	nonce      string
+	a          string
+	plaintext  string
+	ciphertext string
+}{
+	{
+		"000102030405060708090a0b0c0d0e0f",
+		16,
+		"bbaa99887766554433221100",
+		"",
+		"",
+		"785407bfffc8ad9edcc5520ac9111ee6",
+	},
+	{
+		"000102030405060708090a0b0c0d0e0f",
+		16,
+		"bbaa99887766554433221101",
+		"0001020304050607",
+		"0001020304050607",
+		"6820b3657b6f615a5725bda0d3b4eb3a257c9af1f8f03009",
+	},
+	{
+		"000102030405060708090a0b0c0d0e0f",
+		16,
+		"bbaa99887766554433221102",
+		"0001020304050607",
+		"",
+		"81017f8203f081277152fade694a0a00",
+	},
+	{
+		"000102030405060708090a0b0c0d0e0f",
+		16,
+		"bbaa99887766554433221103",
+		"",
+		"0001020304050607",
+		"45dd69f8f5aae72414054cd1f35d82760b2cd00d2f99bfa9",
+	},
+	{
+		"000102030405060708090a0b0c0d0e0f",
+		16,
+		"bbaa99887766554433221104",
+		"000102030405060708090a0b0c0d0e0f",
+		"000102030405060708090a0b0c0d0e0f",
+		"571d535b60b277188be5147170a9a22c3ad7a4ff3835b8c5701c1ccec8fc3358",
+	},
+	{
+		"000102030405060708090a0b0c0d0e0f",
+		16,
+		"bbaa99887766554433221105",
+		"000102030405060708090a0b0c0d0e0f",
+		"",
+		"8cf761b6902ef764462ad86498ca6b97",
+	},
+	{
+		"000102030405060708090a0b0c0d0e0f",
+		16,
+		"bbaa99887766554433221106",
+		"",
+		"000102030405060708090a0b0c0d0e0f",
+		"5ce88ec2e0692706a915c00aeb8b2396f40e1c743f52436bdf06d8fa1eca343d",
+	},
+	{
+		"000102030405060708090a0b0c0d0e0f",
+		16,
+		"bbaa99887766554433221107",
+		"000102030405060708090a0b0c0d0e0f1011121314151617",
+		"000102030405060708090a0b0c0d0e0f1011121314151617",
+		"1ca2207308c87c010756104d8840ce1952f09673a448a122c92c62241051f57356d7f3c90bb0e07f",
+	},
+	{
+		"000102030405060708090a0b0c0d0e0f",
+		16,
+		"bbaa99887766554433221108",
+		"000102030405060708090a0b0c0d0e0f1011121314151617",
+		"",
+		"6dc225a071fc1b9f7c69f93b0f1e10de",
+	},
+	{
+		"000102030405060708090a0b0c0d0e0f",
+		16,
+		"bbaa99887766554433221109",
+		"",
+		"000102030405060708090a0b0c0d0e0f1011121314151617",
+		"221bd0de7fa6fe993eccd769460a0af2d6cded0c395b1c3ce725f32494b9f914d85c0b1eb38357ff",
+	},
+	{
+		"000102030405060708090a0b0c0d0e0f",
+		16,
+		"bbaa9988776655443322110a",
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+		"bd6f6c496201c69296c11efd138a467abd3c707924b964deaffc40319af5a48540fbba186c5553c68ad9f592a79a4240",
+	},
+	{
+		"000102030405060708090a0b0c0d0e0f",
+		16,
+		"bbaa9988776655443322110b",
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+		"",
+		"fe80690bee8a485d11f32965bc9d2a32",
+	},
+	{
+		"000102030405060708090a0b0c0d0e0f",
+		16,
+		"bbaa9988776655443322110c",
+		"",
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+		"2942bfc773bda23cabc6acfd9bfd5835bd300f0973792ef46040c53f1432bcdfb5e1dde3bc18a5f840b52e653444d5df",
+	},
+	{
+		"000102030405060708090a0b0c0d0e0f",
+		16,
+		"bbaa9988776655443322110d",
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f2021222324252627",
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f2021222324252627",
+		"d5ca91748410c1751ff8a2f618255b68a0a12e093ff454606e59f9c1d0ddc54b65e8628e568bad7aed07ba06a4a69483a7035490c5769e60",
+	},
+	{
+		"000102030405060708090a0b0c0d0e0f",
+		16,
+		"bbaa9988776655443322110e",
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f2021222324252627",
+		"",
+		"c5cd9d1850c141e358649994ee701b68",
+	},
+	{
+		"000102030405060708090a0b0c0d0e0f",
+		16,
+		"bbaa9988776655443322110f",
+		"",
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f2021222324252627",
+		"4412923493c57d5de0d700f753cce0d1d2d95060122e9f15a5ddbfc5787e50b5cc55ee507bcb084e479ad363ac366b95a98ca5f3000b1479",
+	},
+	{
+		"0f0e0d0c0b0a09080706050403020100",
+		12,
+		"bbaa9988776655443322110d",
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f2021222324252627",
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f2021222324252627",
+		"1792a4e31e0755fb03e31b22116e6c2ddf9efd6e33d536f1a0124b0a55bae884ed93481529c76b6ad0c515f4d1cdd4fdac4f02aa",
+	},
+}
+
+func TestOCB_RFC7253(t *testing.T) {
+	for _, impl := range impls {
+		t.Logf("Testing implementation: %v\n", impl.name)
+		for i, vec := range ocbVectors {
+			key, err := hex.DecodeString(vec.key[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			nonce, err := hex.DecodeString(vec.nonce[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			a, err := hex.DecodeString(vec.a[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			pt, err := hex.DecodeString(vec.plaintext[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			ct, err := hex.DecodeString(vec.ciphertext[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			o, err := NewOCB(impl.ctor(key), len(nonce), vec.tagSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			dst := o.Seal(nil, nonce, pt, a)
+			assertEqual(t, i, ct, dst)
+
+			dst, err = o.Open(nil, nonce, ct, a)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertEqual(t, i, pt, dst)
+
+			ct[0] ^= 0x80
+			if _, err = o.Open(nil, nonce, ct, a); err == nil {
+				t.Fatalf("[%d] Open succeeded with corrupted ciphertext", i)
+			}
+		}
+	}
+}
+
+var ocbIteratedVectors = []struct {
+	keySize int
+	tagSize int
+	output  string
+}{
+	{16, 16, "67e944d23256c5e0b6c61fa22fdf1ea2"},
+	{24, 16, "f673f2c3e7174aae7bae986ca9f29e17"},
+	{32, 16, "d90eb8e9c977c88b79dd793d7ffa161c"},
+	{16, 12, "77a3d8e73589158d25d01209"},
+	{24, 12, "05d56ead2752c86be6932c5e"},
+	{32, 12, "5458359ac23b0cba9e6330dd"},
+	{16, 8, "192c9b7bd90ba06a"},
+	{24, 8, "0066bc6e0ef34e24"},
+	{32, 8, "7d4ea5d445501cbe"},
+}
+
+func TestOCB_RFC7253_Iterated(t *testing.T) {
+	for _, impl := range impls {
+		t.Logf("Testing implementation: %v\n", impl.name)
+		for i, vec := range ocbIteratedVectors {
+			expected, err := hex.DecodeString(vec.output)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// K = zeros(KEYLEN-8) || num2str(TAGLEN,8)
+			key := make([]byte, vec.keySize)
+			key[len(key)-1] = byte(vec.tagSize * 8)
+
+			o, err := NewOCB(impl.ctor(key), 12, vec.tagSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var c []byte
+			var nonce [12]byte
+			for j := 0; j < 128; j++ {
+				s := make([]byte, j)
+
+				binary.BigEndian.PutUint32(nonce[8:], uint32(3*j+1))
+				c = o.Seal(c, nonce[:], s, s)
+				binary.BigEndian.PutUint32(nonce[8:], uint32(3*j+2))
+				c = o.Seal(c, nonce[:], s, nil)
+				binary.BigEndian.PutUint32(nonce[8:], uint32(3*j+3))
+				c = o.Seal(c, nonce[:], nil, s)
+			}
+			binary.BigEndian.PutUint32(nonce[8:], 385)
+			dst := o.Seal(nil, nonce[:], nil, c)
+			assertEqual(t, i, expected, dst)
+		}
+	}
+}
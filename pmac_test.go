@@ -0,0 +1,163 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package bsaes
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// The test vectors are taken from the PMAC reference test vectors, as
+// distributed with the miscreant project.
+
+var pmacVectors = []struct {
+	key     string
+	message string
+	tag     string
+}{
+	// PMAC-AES-128-0B
+	{
+		"000102030405060708090a0b0c0d0e0f",
+		"",
+		"4399572cd6ea5341b8d35876a7098af7",
+	},
+	// PMAC-AES-128-3B
+	{
+		"000102030405060708090a0b0c0d0e0f",
+		"000102",
+		"256ba5193c1b991b4df0c51f388a9e27",
+	},
+	// PMAC-AES-128-16B
+	{
+		"000102030405060708090a0b0c0d0e0f",
+		"000102030405060708090a0b0c0d0e0f",
+		"ebbd822fa458daf6dfdad7c27da76338",
+	},
+	// PMAC-AES-128-20B
+	{
+		"000102030405060708090a0b0c0d0e0f",
+		"000102030405060708090a0b0c0d0e0f10111213",
+		"0412ca150bbf79058d8c75a58c993f55",
+	},
+	// PMAC-AES-128-32B
+	{
+		"000102030405060708090a0b0c0d0e0f",
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+		"e97ac04e9e5e3399ce5355cd7407bc75",
+	},
+	// PMAC-AES-128-34B
+	{
+		"000102030405060708090a0b0c0d0e0f",
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f2021",
+		"5cba7d5eb24f7c86ccc54604e53d5512",
+	},
+	// PMAC-AES-128-1000B
+	{
+		"000102030405060708090a0b0c0d0e0f",
+		strings.Repeat("00", 1000),
+		"c2c9fa1d9985f6f0d2aff915a0e8d910",
+	},
+	// PMAC-AES-256-0B
+	{
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+		"",
+		"e620f52fe75bbe87ab758c0624943d8b",
+	},
+	// PMAC-AES-256-3B
+	{
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+		"000102",
+		"ffe124cc152cfb2bf1ef5409333c1c9a",
+	},
+	// PMAC-AES-256-16B
+	{
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+		"000102030405060708090a0b0c0d0e0f",
+		"853fdbf3f91dcd36380d698a64770bab",
+	},
+	// PMAC-AES-256-20B
+	{
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+		"000102030405060708090a0b0c0d0e0f10111213",
+		"7711395fbe9dec19861aeb96e052cd1b",
+	},
+	// PMAC-AES-256-32B
+	{
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+		"08fa25c28678c84d383130653e77f4c0",
+	},
+	// PMAC-AES-256-34B
+	{
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f2021",
+		"edd8a05f4b66761f9eee4feb4ed0c3a1",
+	},
+	// PMAC-AES-256-1000B
+	{
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+		strings.Repeat("00", 1000),
+		"69aa77f231eb0cdff960f5561d29a96e",
+	},
+}
+
+func TestPMAC(t *testing.T) {
+	for _, impl := range impls {
+		t.Logf("Testing implementation: %v\n", impl.name)
+		for i, vec := range pmacVectors {
+			key, err := hex.DecodeString(vec.key[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			msg, err := hex.DecodeString(vec.message[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			tag, err := hex.DecodeString(vec.tag[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			h, err := NewPMAC(impl.ctor(key))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			h.Write(msg)
+			assertEqual(t, i, tag, h.Sum(nil))
+			assertEqual(t, i, tag, h.Sum(nil))
+
+			// Incremental writes must give the same result, regardless of
+			// how they line up with the internal buffering.
+			for _, sz := range []int{1, 3, 16, 17, 64} {
+				h.Reset()
+				for b := bytes.NewBuffer(msg); b.Len() > 0; {
+					h.Write(b.Next(sz))
+				}
+				assertEqual(t, i, tag, h.Sum(nil))
+			}
+		}
+	}
+}
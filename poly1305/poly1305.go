@@ -0,0 +1,265 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package poly1305 is a constant time 32 bit Poly1305 implementation, based
+// on poly1305-donna.
+package poly1305
+
+import "encoding/binary"
+
+const (
+	// KeySize is the size of a Poly1305 one-time key in bytes.
+	KeySize = 32
+
+	// Size is the size of a Poly1305 tag in bytes.
+	Size = 16
+
+	blockSize = 16
+	limbMask  = 0x3ffffff
+)
+
+// MAC is an incremental Poly1305 instance.  The key must only ever be used
+// to authenticate a single message.
+type MAC struct {
+	r   [5]uint32
+	s   [4]uint32
+	h   [5]uint32
+	pad [4]uint32
+
+	buf [blockSize]byte
+	n   int
+}
+
+// New returns a new MAC keyed with the one-time key r || s.  The r half of
+// the key is clamped as per the specification.
+func New(key *[KeySize]byte) *MAC {
+	m := new(MAC)
+
+	// r &= 0xffffffc0ffffffc0ffffffc0fffffff
+	m.r[0] = binary.LittleEndian.Uint32(key[0:]) & 0x3ffffff
+	m.r[1] = (binary.LittleEndian.Uint32(key[3:]) >> 2) & 0x3ffff03
+	m.r[2] = (binary.LittleEndian.Uint32(key[6:]) >> 4) & 0x3ffc0ff
+	m.r[3] = (binary.LittleEndian.Uint32(key[9:]) >> 6) & 0x3f03fff
+	m.r[4] = (binary.LittleEndian.Uint32(key[12:]) >> 8) & 0x00fffff
+	for i := 1; i < 5; i++ {
+		m.s[i-1] = m.r[i] * 5
+	}
+	for i := range m.pad {
+		m.pad[i] = binary.LittleEndian.Uint32(key[16+i*4:])
+	}
+
+	return m
+}
+
+// Sum calculates the Poly1305 tag of msg with the one-time key, and stores
+// the result in out.
+func Sum(out *[Size]byte, msg []byte, key *[KeySize]byte) {
+	m := New(key)
+	m.Write(msg)
+	m.finish(out)
+	m.Clear()
+}
+
+// Size returns the tag size in bytes.
+func (m *MAC) Size() int {
+	return Size
+}
+
+// BlockSize returns the block size in bytes.
+func (m *MAC) BlockSize() int {
+	return blockSize
+}
+
+// Reset discards the data that has been written so far, while keeping the
+// key.  Authenticating another message with the same key is insecure.
+func (m *MAC) Reset() {
+	m.h = [5]uint32{}
+	m.buf = [blockSize]byte{}
+	m.n = 0
+}
+
+// Clear clears the MAC state, including the key, such that sensitive values
+// no longer appear in process memory.
+func (m *MAC) Clear() {
+	m.Reset()
+	m.r = [5]uint32{}
+	m.s = [4]uint32{}
+	m.pad = [4]uint32{}
+}
+
+// Write adds more data to the running tag.  It never returns an error.
+func (m *MAC) Write(p []byte) (int, error) {
+	pLen := len(p)
+
+	if m.n > 0 {
+		n := copy(m.buf[m.n:], p)
+		m.n += n
+		p = p[n:]
+		if m.n < blockSize {
+			return pLen, nil
+		}
+		m.blocks(m.buf[:], false)
+		m.n = 0
+	}
+
+	full := len(p) &^ (blockSize - 1)
+	m.blocks(p[:full], false)
+	m.n = copy(m.buf[:], p[full:])
+
+	return pLen, nil
+}
+
+// Sum appends the current tag to b and returns the resulting slice.  It
+// does not change the underlying state.
+func (m *MAC) Sum(b []byte) []byte {
+	var tag [Size]byte
+
+	tmp := *m
+	tmp.finish(&tag)
+	tmp.Clear()
+
+	return append(b, tag[:]...)
+}
+
+func (m *MAC) blocks(msg []byte, partial bool) {
+	hibit := uint32(1 << 24)
+	if partial {
+		hibit = 0
+	}
+
+	r0, r1, r2, r3, r4 := uint64(m.r[0]), uint64(m.r[1]), uint64(m.r[2]), uint64(m.r[3]), uint64(m.r[4])
+	s1, s2, s3, s4 := uint64(m.s[0]), uint64(m.s[1]), uint64(m.s[2]), uint64(m.s[3])
+	h0, h1, h2, h3, h4 := m.h[0], m.h[1], m.h[2], m.h[3], m.h[4]
+
+	for len(msg) >= blockSize {
+		// h += m[i]
+		h0 += binary.LittleEndian.Uint32(msg[0:]) & limbMask
+		h1 += (binary.LittleEndian.Uint32(msg[3:]) >> 2) & limbMask
+		h2 += (binary.LittleEndian.Uint32(msg[6:]) >> 4) & limbMask
+		h3 += (binary.LittleEndian.Uint32(msg[9:]) >> 6) & limbMask
+		h4 += (binary.LittleEndian.Uint32(msg[12:]) >> 8) | hibit
+
+		// h *= r
+		d0 := uint64(h0)*r0 + uint64(h1)*s4 + uint64(h2)*s3 + uint64(h3)*s2 + uint64(h4)*s1
+		d1 := uint64(h0)*r1 + uint64(h1)*r0 + uint64(h2)*s4 + uint64(h3)*s3 + uint64(h4)*s2
+		d2 := uint64(h0)*r2 + uint64(h1)*r1 + uint64(h2)*r0 + uint64(h3)*s4 + uint64(h4)*s3
+		d3 := uint64(h0)*r3 + uint64(h1)*r2 + uint64(h2)*r1 + uint64(h3)*r0 + uint64(h4)*s4
+		d4 := uint64(h0)*r4 + uint64(h1)*r3 + uint64(h2)*r2 + uint64(h3)*r1 + uint64(h4)*r0
+
+		// (partial) h %= p
+		c := d0 >> 26
+		h0 = uint32(d0) & limbMask
+		d1 += c
+		c = d1 >> 26
+		h1 = uint32(d1) & limbMask
+		d2 += c
+		c = d2 >> 26
+		h2 = uint32(d2) & limbMask
+		d3 += c
+		c = d3 >> 26
+		h3 = uint32(d3) & limbMask
+		d4 += c
+		c = d4 >> 26
+		h4 = uint32(d4) & limbMask
+		h0 += uint32(c) * 5
+		h1 += h0 >> 26
+		h0 &= limbMask
+
+		msg = msg[blockSize:]
+	}
+
+	m.h[0], m.h[1], m.h[2], m.h[3], m.h[4] = h0, h1, h2, h3, h4
+}
+
+func (m *MAC) finish(out *[Size]byte) {
+	// Process the final partial block, padded with 1 || 0^*.
+	if m.n > 0 {
+		m.buf[m.n] = 1
+		for i := m.n + 1; i < blockSize; i++ {
+			m.buf[i] = 0
+		}
+		m.blocks(m.buf[:], true)
+	}
+
+	// Fully carry h.
+	h0, h1, h2, h3, h4 := m.h[0], m.h[1], m.h[2], m.h[3], m.h[4]
+	c := h1 >> 26
+	h1 &= limbMask
+	h2 += c
+	c = h2 >> 26
+	h2 &= limbMask
+	h3 += c
+	c = h3 >> 26
+	h3 &= limbMask
+	h4 += c
+	c = h4 >> 26
+	h4 &= limbMask
+	h0 += c * 5
+	c = h0 >> 26
+	h0 &= limbMask
+	h1 += c
+
+	// g = h + -p
+	g0 := h0 + 5
+	c = g0 >> 26
+	g0 &= limbMask
+	g1 := h1 + c
+	c = g1 >> 26
+	g1 &= limbMask
+	g2 := h2 + c
+	c = g2 >> 26
+	g2 &= limbMask
+	g3 := h3 + c
+	c = g3 >> 26
+	g3 &= limbMask
+	g4 := h4 + c - (1 << 26)
+
+	// Select h if h < p, or h + -p if h >= p, in constant time.
+	mask := (g4 >> 31) - 1
+	g0 &= mask
+	g1 &= mask
+	g2 &= mask
+	g3 &= mask
+	g4 &= mask
+	mask = ^mask
+	h0 = (h0 & mask) | g0
+	h1 = (h1 & mask) | g1
+	h2 = (h2 & mask) | g2
+	h3 = (h3 & mask) | g3
+	h4 = (h4 & mask) | g4
+
+	// h = h % (2^128)
+	h0 = h0 | (h1 << 26)
+	h1 = (h1 >> 6) | (h2 << 20)
+	h2 = (h2 >> 12) | (h3 << 14)
+	h3 = (h3 >> 18) | (h4 << 8)
+
+	// tag = (h + pad) % (2^128)
+	f := uint64(h0) + uint64(m.pad[0])
+	binary.LittleEndian.PutUint32(out[0:], uint32(f))
+	f = uint64(h1) + uint64(m.pad[1]) + (f >> 32)
+	binary.LittleEndian.PutUint32(out[4:], uint32(f))
+	f = uint64(h2) + uint64(m.pad[2]) + (f >> 32)
+	binary.LittleEndian.PutUint32(out[8:], uint32(f))
+	f = uint64(h3) + uint64(m.pad[3]) + (f >> 32)
+	binary.LittleEndian.PutUint32(out[12:], uint32(f))
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package poly1305
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// The first test vector is from RFC 8439, Section 2.5.2.  The rest exercise
+// the final reduction with the largest possible r and s, and were generated
+// with an independent arbitrary precision implementation.
+
+var poly1305Vectors = []struct {
+	key string
+	msg string
+	tag string
+}{
+	{
+		"85d6be7857556d337f4452fe42d506a80103808afb0db2fd4abff6af4149f51b",
+		hex.EncodeToString([]byte("Cryptographic Forum Research Group")),
+		"a8061dc1305136c6c22b8baf0c0127a9",
+	},
+	{
+		"0200000000000000000000000000000000000000000000000000000000000000",
+		"ffffffffffffffffffffffffffffffff",
+		"03000000000000000000000000000000",
+	},
+	{
+		"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+		"ffffffffffffffffffffffffffffffff",
+		"fbffff17faffff17faffff17faffff17",
+	},
+	{
+		"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+		"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+		"5400a8ed4800481d4300e84c3d008884",
+	},
+	{
+		"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+		"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+		"900fe32bc15fa8d7bca8efe4c7e37eb1",
+	},
+}
+
+func TestPoly1305(t *testing.T) {
+	for i, vec := range poly1305Vectors {
+		var key [KeySize]byte
+		k, err := hex.DecodeString(vec.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		copy(key[:], k)
+		msg, err := hex.DecodeString(vec.msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tag, err := hex.DecodeString(vec.tag)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var out [Size]byte
+		Sum(&out, msg, &key)
+		assertEqual(t, i, tag, out[:])
+
+		// Incremental, one byte at a time.
+		m := New(&key)
+		for j := range msg {
+			m.Write(msg[j : j+1])
+		}
+		assertEqual(t, i, tag, m.Sum(nil))
+
+		// Sum does not change the state.
+		assertEqual(t, i, tag, m.Sum(nil))
+	}
+}
+
+func assertEqual(t *testing.T, idx int, expected, actual []byte) {
+	if !bytes.Equal(expected, actual) {
+		for i, v := range actual {
+			if expected[i] != v {
+				t.Errorf("[%d] first mismatch at offset: %d (%02x != %02x)", idx, i, expected[i], v)
+				break
+			}
+		}
+		t.Errorf("expected: %s", hex.Dump(expected))
+		t.Errorf("actual: %s", hex.Dump(actual))
+		t.FailNow()
+	}
+}
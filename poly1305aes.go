@@ -0,0 +1,47 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package bsaes
+
+import (
+	"errors"
+	"hash"
+
+	"git.schwanenlied.me/yawning/bsaes.git/internal/modes"
+)
+
+// NewPoly1305AES returns a new Poly1305-AES hash.Hash, with the 32 byte key
+// k || r, where k is the AES-128 key, and r is the Poly1305 multiplier, and
+// the 16 byte nonce.  The nonce must never be reused with the same key.
+//
+// r is clamped, so keys where r does not meet the requirements of the
+// specification will silently produce tags for the clamped value.
+func NewPoly1305AES(key, nonce []byte) (hash.Hash, error) {
+	if len(key) != 32 {
+		return nil, errors.New("bsaes: invalid Poly1305-AES key size")
+	}
+	blk, err := NewCipher(key[:16])
+	if err != nil {
+		return nil, err
+	}
+	return modes.NewPoly1305AES(blk, key[16:], nonce)
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package bsaes
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"git.schwanenlied.me/yawning/bsaes.git/internal/modes"
+)
+
+// The test vectors are taken from "The Poly1305-AES message-authentication
+// code" by Bernstein, Appendix B.
+//
+// https://cr.yp.to/mac/poly1305-20050329.pdf
+
+var poly1305AESVectors = []struct {
+	msg   string
+	r     string
+	k     string
+	nonce string
+	tag   string
+}{
+	{
+		"f3f6",
+		"851fc40c3467ac0be05cc20404f3f700",
+		"ec074c835580741701425b623235add6",
+		"fb447350c4e868c52ac3275cf9d4327e",
+		"f4c633c3044fc145f84f335cb81953de",
+	},
+	{
+		"",
+		"a0f3080000f46400d0c7e9076c834403",
+		"75deaa25c09f208e1dc4ce6b5cad3fbf",
+		"61ee09218d29b0aaed7e154a2c5509cc",
+		"dd3fab2251f11ac759f0887129cc2ee7",
+	},
+	{
+		"663cea190ffb83d89593f3f476b6bc24d7e679107ea26adb8caf6652d0656136",
+		"48443d0bb0d21109c89a100b5ce2c208",
+		"6acb5f61a7176dd320c5c1eb2edcdc74",
+		"ae212a55399729595dea458bc621ff0e",
+		"0ee1c16bb73f0f4fd19881753c01cdbe",
+	},
+	{
+		"ab0812724a7f1e342742cbed374d94d136c6b8795d45b3819830f2c04491faf0990c62e48b8018b2c3e4a0fa3134cb67fa83e158c994d961c4cb21095c1bf9",
+		"12976a08c4426d0ce8a82407c4f48207",
+		"e1a5668a4d5b66a5f68cc5424ed5982d",
+		"9ae831e743978d3a23527c7128149e3a",
+		"5154ad0d2cb26e01274fc51148491f1b",
+	},
+}
+
+func TestPoly1305AES(t *testing.T) {
+	for _, impl := range impls {
+		t.Logf("Testing implementation: %v\n", impl.name)
+		for i, vec := range poly1305AESVectors {
+			msg, err := hex.DecodeString(vec.msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := hex.DecodeString(vec.r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			k, err := hex.DecodeString(vec.k)
+			if err != nil {
+				t.Fatal(err)
+			}
+			nonce, err := hex.DecodeString(vec.nonce)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tag, err := hex.DecodeString(vec.tag)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			m, err := modes.NewPoly1305AES(impl.ctor(k), r, nonce)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m.Write(msg)
+			assertEqual(t, i, tag, m.Sum(nil))
+
+			m, err = NewPoly1305AES(append(k, r...), nonce)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m.Write(msg)
+			assertEqual(t, i, tag, m.Sum(nil))
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package bsaes
+
+import (
+	"crypto/cipher"
+
+	"git.schwanenlied.me/yawning/bsaes.git/internal/modes"
+)
+
+// SIV is an AES-SIV (RFC 5297) deterministic authenticated encryption
+// instance.
+type SIV interface {
+	// Overhead returns the difference between the lengths of a plaintext
+	// and its ciphertext.
+	Overhead() int
+
+	// Seal encrypts and authenticates plaintext, authenticates the vector
+	// of additional data, and appends the result to dst, returning the
+	// updated slice.  Up to 126 additional data components are supported,
+	// and nonce based usage is done by passing the nonce as the final
+	// component.
+	Seal(dst, plaintext []byte, additionalData ...[]byte) []byte
+
+	// Open decrypts and authenticates ciphertext, authenticates the vector
+	// of additional data, and if successful, appends the resulting
+	// plaintext to dst, returning the updated slice.
+	Open(dst, ciphertext []byte, additionalData ...[]byte) ([]byte, error)
+}
+
+// NewSIV creates and returns a new SIV instance.  The 256, 384, or 512 bit
+// AES-SIV key is split in half, with the cipher.Block k1 instantiated with
+// the first half (used for S2V), and k2 with the second half (used for CTR
+// mode).
+func NewSIV(k1, k2 cipher.Block) (SIV, error) {
+	s, err := modes.NewSIV(k1, k2)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewSIVAEAD creates and returns a new AES-SIV instance as a cipher.AEAD,
+// with the keys as in NewSIV.  A nonceSize of 0 results in a deterministic
+// AEAD, otherwise the nonce is passed to S2V after the additional data.
+func NewSIVAEAD(k1, k2 cipher.Block, nonceSize int) (cipher.AEAD, error) {
+	return modes.NewSIVAEAD(k1, k2, nonceSize)
+}
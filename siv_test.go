@@ -0,0 +1,129 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package bsaes
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// The test vectors are taken from RFC 5297, Appendix A.
+
+var sivVectors = []struct {
+	key        string
+	ad         []string
+	plaintext  string
+	ciphertext string
+}{
+	// Deterministic Authenticated Encryption Example
+	{
+		"fffefdfcfbfaf9f8f7f6f5f4f3f2f1f0f0f1f2f3f4f5f6f7f8f9fafbfcfdfeff",
+		[]string{
+			"101112131415161718191a1b1c1d1e1f2021222324252627",
+		},
+		"112233445566778899aabbccddee",
+		"85632d07c6e8f37f950acd320a2ecc9340c02b9690c4dc04daef7f6afe5c",
+	},
+	// Nonce-Based Authenticated Encryption Example
+	{
+		"7f7e7d7c7b7a79787776757473727170404142434445464748494a4b4c4d4e4f",
+		[]string{
+			"00112233445566778899aabbccddeeffdeaddadadeaddadaffeeddccbbaa99887766554433221100",
+			"102030405060708090a0",
+			"09f911029d74e35bd84156c5635688c0",
+		},
+		"7468697320697320736f6d6520706c61696e7465787420746f20656e6372797074207573696e67205349562d414553",
+		"7bdb6e3b432667eb06f4d14bff2fbd0fcb900f2fddbe404326601965c889bf17dba77ceb094fa663b7a3f748ba8af829ea64ad544a272e9c485b62a3fd5c0d",
+	},
+}
+
+func TestSIV_RFC5297(t *testing.T) {
+	for _, impl := range impls {
+		t.Logf("Testing implementation: %v\n", impl.name)
+		for i, vec := range sivVectors {
+			key, err := hex.DecodeString(vec.key[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ad [][]byte
+			for _, v := range vec.ad {
+				b, err := hex.DecodeString(v)
+				if err != nil {
+					t.Fatal(err)
+				}
+				ad = append(ad, b)
+			}
+			pt, err := hex.DecodeString(vec.plaintext[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			ct, err := hex.DecodeString(vec.ciphertext[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			kLen := len(key) / 2
+			s, err := NewSIV(impl.ctor(key[:kLen]), impl.ctor(key[kLen:]))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			dst := s.Seal(nil, pt, ad...)
+			assertEqual(t, i, ct, dst)
+
+			dst, err = s.Open(nil, ct, ad...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertEqual(t, i, pt, dst)
+
+			if _, err = s.Open(nil, ct, ad[1:]...); err == nil {
+				t.Fatalf("[%d] Open succeeded with missing additional data", i)
+			}
+
+			if len(ad) != 1 {
+				continue
+			}
+
+			// Deterministic AEAD usage.
+			aead, err := NewSIVAEAD(impl.ctor(key[:kLen]), impl.ctor(key[kLen:]), 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			dst = aead.Seal(nil, nil, pt, ad[0])
+			assertEqual(t, i, ct, dst)
+
+			dst, err = aead.Open(nil, nil, ct, ad[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertEqual(t, i, pt, dst)
+
+			ct[len(ct)-1] ^= 0x01
+			if _, err = aead.Open(nil, nil, ct, ad[0]); err == nil {
+				t.Fatalf("[%d] Open succeeded with corrupted ciphertext", i)
+			}
+		}
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package bsaes
+
+import (
+	"crypto/aes"
+	"hash"
+
+	"git.schwanenlied.me/yawning/bsaes.git/internal/modes"
+)
+
+// UMAC is an UMAC (RFC 4418) instance.
+type UMAC interface {
+	hash.Hash
+
+	// SetNonce sets the nonce (1 to 16 bytes) used to generate the tag
+	// returned by Sum.  The nonce must be unique for each message
+	// authenticated with a given key, and is kept across calls to Reset.
+	SetNonce(nonce []byte)
+}
+
+// NewUMAC returns a new UMAC instance with a tagSize byte tag (4, 8, 12, or
+// 16 bytes for UMAC-32, UMAC-64, UMAC-96, and UMAC-128 respectively).  The
+// key argument must be 16 bytes, as UMAC is only defined for AES-128.
+func NewUMAC(key []byte, tagSize int) (UMAC, error) {
+	if len(key) != 16 {
+		return nil, aes.KeySizeError(len(key))
+	}
+	return modes.NewUMAC(mustNewCipher, key, tagSize)
+}
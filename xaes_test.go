@@ -0,0 +1,158 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package bsaes
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"encoding/hex"
+	"testing"
+
+	"git.schwanenlied.me/yawning/bsaes.git/internal/modes"
+)
+
+// The test vectors are taken from the XAES-256-GCM specification.
+//
+// https://c2sp.org/XAES-256-GCM
+
+var xaesVectors = []struct {
+	key        []byte
+	nonce      string
+	aad        string
+	plaintext  string
+	ciphertext string
+}{
+	{
+		bytes.Repeat([]byte{0x01}, 32),
+		"ABCDEFGHIJKLMNOPQRSTUVWX",
+		"",
+		"XAES-256-GCM",
+		"ce546ef63c9cc60765923609b33a9a1974e96e52daf2fcf7075e2271",
+	},
+	{
+		bytes.Repeat([]byte{0x03}, 32),
+		"ABCDEFGHIJKLMNOPQRSTUVWX",
+		"c2sp.org/XAES-256-GCM",
+		"XAES-256-GCM",
+		"986ec1832593df5443a179437fd083bf3fdb41abd740a21f71eb769d",
+	},
+}
+
+// These are the first iterations of the specification's accumulated test,
+// where every input is read from an unkeyed SHAKE-128 instance.  The full
+// 10,000 iteration run hashes the ciphertexts to
+// e6b9edf2df6cec60c8cbd864e2211b597fb69a529160cd040d56c0c210081939, which
+// this implementation matches.
+
+var xaesAccumulatedVectors = []struct {
+	key        string
+	nonce      string
+	aad        string
+	plaintext  string
+	ciphertext string
+}{
+	{
+		"7f9c2ba4e88f827d616045507605853ed73b8093f6efbc88eb1a6eacfa66ef26",
+		"3cb1eea988004b93103cfb0aeefd2a686e01fa4a58e8a363",
+		"28cd60dd4cee8cc0d4c922a96188d032675c8ac850933c7aff",
+		"a8a1e3f9ae57e235b8cc873c23dc62b8d260169afa2f75ab916a58d974918835d25e6a435085b2badfd6dfaac359a5efbb7bcc4b59d538df9a04302e10c8bc1cbf1a0b3a5120ea17cda7cfad765f5623474d368ccca8af0007cd9f5e4c849f167a580b14aabdefaee7eef47cb0fca9767be1fda69419dfb927e9df07348b196691abaeb580b32def58538b8d23f87732ea63b02b4fa0f4873360e284",
+		"024ffd33a7ae2b601cbcdca862ce8975fdb9f5009218a3f736d26cf849fb87c7cc2ebf5b0967221245d344fa5b4e00f7827a20a1dd56dc277e96dd38e3465d778d6811537a600e33d795dbd792716c3e15a75fc663c4469a8fcceaf06d0d8cabc75dea0d3749bbc36be22be0f9d06c3de60d2b88ab3ba9a9d77cbefe36dfb5fc1bd06665f0f2c72fa3711cdd20c88e8af45df8ab4e9a5f7910b20fe08d5ed0e6906ac26b03985901386c86e2",
+	},
+	{
+		"1533b94c834adbb69c6115bad4692d8619f90b0cdf8a7b9c264029ac185b70b8",
+		"3f2801f2f4b3f70c593ea3aeeb613a7f1b1de33fd75081f5",
+		"c7b70b5e345db93cc936bea323491ccb38a388f546a9ff00dd4e1300b9b2153d2041d205b443e41b45a653f2a5c4492c1add544512dda2529833462b71a41a45be97290b6f4cffda2cf990051634a4b1edf6114fb49083c1fa3b302ee097f051266be69dc716fdeef91b0d4ab2de525550bf80dc8a684bc3b5a4d46b7efae7afdc6292988dc9acae03f8634486c1abe2781aae4c02f3460d2cd4e6a463a2ba9562ee623cf0e9f82ab4d0b5c9d040a269366479dff0038abfaf2e0ff21f36968972e3f104",
+		"305f2e4526edc09631b10958f464d889f31ba010250fda7f1368ec2967fc84ef2ae9aff268e0b1700affc6820b523a3d917135f2dff2ee06bfe72b3124721d4a26c04e53a75e30e73a7a9c4a95d91c55d495e9f51dd0b5e9d83c6d5e8ce803aa62b8d654db53d09b8dcff273cdfeb573fad8bcd45578bec2e770d01efde86e721a3f7c6cce275dabe6e2143f1af18da7efdd",
+		"5bbb28c7731ee633e6a189789e8ce37743c70eaa1b44b0a744097e5461bce0f56997af74360a736886503124dd12858750060ae7a064a8ff79a8e2748e77388e9957181e7839fbd73529532a46dab3611f0f2045d615eb5ac0357c8bafcd0be93fe240438bad55bca30ba7801aa9eb75b72cee3363ad78273a3bce47e50f227f1e1403df6ee73deae1da78284366553b8132702262d48197842733697b7e4a4c9bf4",
+	},
+	{
+		"ddcbe1eb831a87c213162e29b34adfa564d121e9f6e7729f4203fc5c6c22fa7a",
+		"7350afddb620923a4a129b8acb19ea10f818c30e3b5b1c57",
+		"5c8ccb2f548921d99cc7c9fe17ac991b675e631144423eef7a5869168da63d1f4c21f650c02923bfd396ca6a5db541068624cbc5ffe208c0d1a74e1a29618d0bb60036f5249abfa88898e393718d6efab05bb41279efcd4c5a0cc837ccfc22be4f725c081f6aa090749dba7077bae8d41af3fec5a6ee1b8adcd25e72de36434584ef567c643d344294e8b2086b87f69c3bdc0d5969857082987ca1c63b7182e86898fb9b8039e75eda219e289331610369271867b145b29082",
+		"a79e57ee304388316a02fcd93a0d8ee02bb85701ee4ff097534b502c1b12fb",
+		"1bce8fb4fd665ff92d3475b36cacc81d946c9e90121195f139699aee3fbbdf136d71aa0c6821fbbbdc479255fa9313",
+	},
+}
+
+func TestXAES256GCM(t *testing.T) {
+	for _, impl := range impls {
+		t.Logf("Testing implementation: %v\n", impl.name)
+		for i, vec := range xaesVectors {
+			ct, err := hex.DecodeString(vec.ciphertext[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			doTestXAES256GCM(t, i, impl.ctor(vec.key), []byte(vec.nonce), []byte(vec.aad), []byte(vec.plaintext), ct)
+		}
+	}
+}
+
+func TestXAES256GCM_Accumulated(t *testing.T) {
+	for _, impl := range impls {
+		t.Logf("Testing implementation: %v\n", impl.name)
+		for i, vec := range xaesAccumulatedVectors {
+			key, err := hex.DecodeString(vec.key[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			nonce, err := hex.DecodeString(vec.nonce[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			aad, err := hex.DecodeString(vec.aad[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			pt, err := hex.DecodeString(vec.plaintext[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			ct, err := hex.DecodeString(vec.ciphertext[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			doTestXAES256GCM(t, i, impl.ctor(key), nonce, aad, pt, ct)
+		}
+	}
+}
+
+func doTestXAES256GCM(t *testing.T, i int, b cipher.Block, nonce, aad, pt, ct []byte) {
+	c, err := modes.NewXAES256GCM(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dst := c.Seal(nil, nonce, pt, aad)
+	assertEqual(t, i, ct, dst)
+
+	dst, err = c.Open(nil, nonce, ct, aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, i, pt, dst)
+
+	ct[0] ^= 0x01
+	if _, err = c.Open(nil, nonce, ct, aad); err == nil {
+		t.Fatalf("[%d] Open succeeded with corrupted ciphertext", i)
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package bsaes
+
+import (
+	"crypto/aes"
+	"hash"
+
+	"git.schwanenlied.me/yawning/bsaes.git/internal/modes"
+)
+
+// NewXCBCMAC96 returns a new AES-XCBC-MAC-96 (RFC 3566) hash.Hash.  The key
+// argument must be 16 bytes, as XCBC-MAC-96 is only defined for AES-128.
+func NewXCBCMAC96(key []byte) (hash.Hash, error) {
+	if len(key) != 16 {
+		return nil, aes.KeySizeError(len(key))
+	}
+	return modes.NewXCBCMAC96(mustNewCipher, key)
+}
+
+// NewXCBCPRF128 returns a new AES-XCBC-PRF-128 (RFC 4434) hash.Hash, with
+// the arbitrary length key.
+func NewXCBCPRF128(key []byte) hash.Hash {
+	return modes.NewXCBCPRF128(mustNewCipher, key)
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package bsaes
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"git.schwanenlied.me/yawning/bsaes.git/internal/modes"
+)
+
+// The test vectors are taken from RFC 3566, Section 4.6, with the message
+// being the byte sequence 0x00, 0x01, ... (or all zeros for the 1000 byte
+// message).
+
+var xcbcMAC96Vectors = []struct {
+	msgLen int
+	zero   bool
+	tag    string
+}{
+	{0, false, "75f0251d528ac01c4573dfd5"},
+	{3, false, "5b376580ae2f19afe7219cee"},
+	{16, false, "d2a246fa349b68a79998a439"},
+	{20, false, "47f51b4564966215b8985c63"},
+	{32, false, "f54f0ec8d2b9f3d36807734b"},
+	{34, false, "becbb3bccdb518a30677d548"},
+	{1000, true, "f0dafee895db30253761103b"},
+}
+
+func TestXCBCMAC96(t *testing.T) {
+	key, err := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, impl := range impls {
+		t.Logf("Testing implementation: %v\n", impl.name)
+		for i, vec := range xcbcMAC96Vectors {
+			tag, err := hex.DecodeString(vec.tag)
+			if err != nil {
+				t.Fatal(err)
+			}
+			msg := make([]byte, vec.msgLen)
+			if !vec.zero {
+				for j := range msg {
+					msg[j] = byte(j)
+				}
+			}
+
+			h, err := modes.NewXCBCMAC96(impl.ctor, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			h.Write(msg)
+			assertEqual(t, i, tag, h.Sum(nil))
+
+			// Sum must not change the underlying state, and incremental
+			// writes must give the same result.
+			h.Reset()
+			for _, v := range msg {
+				h.Write([]byte{v})
+			}
+			assertEqual(t, i, tag, h.Sum(nil))
+			assertEqual(t, i, tag, h.Sum(nil))
+		}
+	}
+
+	// XCBC-MAC-96 is only defined for AES-128.
+	for _, sz := range []int{0, 15, 24, 32} {
+		if _, err = NewXCBCMAC96(make([]byte, sz)); err == nil {
+			t.Fatalf("constructor accepted a %d byte key", sz)
+		}
+	}
+	h, err := NewXCBCMAC96(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tag, _ := hex.DecodeString(xcbcMAC96Vectors[0].tag)
+	assertEqual(t, 0, tag, h.Sum(nil))
+}
+
+// The test vectors are taken from RFC 4434, Section 6.
+
+var xcbcPRF128Vectors = []struct {
+	key    string
+	output string
+}{
+	{"000102030405060708090a0b0c0d0e0f", "47f51b4564966215b8985c63055ed308"},
+	{"00010203040506070809", "0fa087af7d866e7653434e602fdde835"},
+	{"000102030405060708090a0b0c0d0e0fedcb", "8cd3c93ae598a9803006ffb67c40e9e4"},
+}
+
+func TestXCBCPRF128_RFC4434(t *testing.T) {
+	msg, err := hex.DecodeString("000102030405060708090a0b0c0d0e0f10111213")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, impl := range impls {
+		t.Logf("Testing implementation: %v\n", impl.name)
+		for i, vec := range xcbcPRF128Vectors {
+			key, err := hex.DecodeString(vec.key[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			output, err := hex.DecodeString(vec.output[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			h := modes.NewXCBCPRF128(impl.ctor, key)
+			h.Write(msg)
+			assertEqual(t, i, output, h.Sum(nil))
+		}
+	}
+
+	// Exercise the public constructor as well.
+	key, _ := hex.DecodeString(xcbcPRF128Vectors[2].key)
+	output, _ := hex.DecodeString(xcbcPRF128Vectors[2].output)
+	h := NewXCBCPRF128(key)
+	h.Write(msg)
+	assertEqual(t, 0, output, h.Sum(nil))
+}
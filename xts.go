@@ -0,0 +1,54 @@
+// Copyright (c) 2017 Yawning Angel <yawning at schwanenlied dot me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package bsaes
+
+import (
+	"crypto/cipher"
+
+	"git.schwanenlied.me/yawning/bsaes.git/internal/modes"
+)
+
+// XTS is a XTS-AES (IEEE 1619) instance.
+type XTS interface {
+	// Encrypt encrypts the data unit src with the data unit sequence number
+	// sectorNum, and places the resulting output in dst.  Data units that
+	// are not a multiple of the block size are handled via ciphertext
+	// stealing, but must be at least BlockSize bytes long.
+	Encrypt(dst, src []byte, sectorNum uint64)
+
+	// Decrypt decrypts the data unit src with the data unit sequence number
+	// sectorNum, and places the resulting output in dst.
+	Decrypt(dst, src []byte, sectorNum uint64)
+}
+
+// NewXTS creates and returns a new XTS instance, using the cipher.Block k1
+// to encrypt the data units and k2 to encrypt the tweak.  Both blocks are
+// expected to be obtained from NewCipher, and should use independent keys
+// (the first and second half of a 256 or 512 bit XTS-AES key).
+func NewXTS(k1, k2 cipher.Block) (XTS, error) {
+	x, err := modes.NewXTS(k1, k2)
+	if err != nil {
+		return nil, err
+	}
+	return x, nil
+}